@@ -61,6 +61,9 @@ type GatewayClient struct {
 	// Raw Http Client to do OAuth2 login.
 	httpClient *http.Client
 
+	// Headers sent with every request.
+	defaultHeaders map[string]string
+
 	// Maximum time to wait (when polling) for a task to become completed.
 	retryTimeout int
 }
@@ -111,6 +114,8 @@ func NewGateClient(gateEndpoint, defaultHeaders, x509_cert string, x509_key stri
 		}
 	}
 
+	gateClient.defaultHeaders = m
+
 	cfg := &gate.Configuration{
 		BasePath:      gateClient.GateEndpoint(),
 		DefaultHeader: m,
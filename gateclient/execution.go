@@ -0,0 +1,94 @@
+package gateclient
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/antihax/optional"
+	gate "github.com/spinnaker/spin/gateapi"
+)
+
+func (m *GatewayClient) InvokePipeline(applicationName, pipelineName string, trigger map[string]interface{}) error {
+	resp, err := m.PipelineControllerApi.InvokePipelineConfigUsingPOST1(m.Context,
+		applicationName,
+		pipelineName,
+		&gate.PipelineControllerApiInvokePipelineConfigUsingPOST1Opts{Trigger: optional.NewInterface(trigger)})
+
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%s", ErrCodeNoSuchEntityException)
+		}
+		return fmt.Errorf("Encountered an error executing pipeline %s, %s\n",
+			pipelineName,
+			err.Error())
+	}
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Encountered an error executing pipeline in application %s with name %s, status code: %d\n",
+			applicationName,
+			pipelineName,
+			resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (m *GatewayClient) GetExecution(executionID string) (map[string]interface{}, error) {
+	execution, resp, err := m.PipelineControllerApi.GetPipelineUsingGET(m.Context, executionID)
+
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s", ErrCodeNoSuchEntityException)
+		}
+		return nil, fmt.Errorf("Encountered an error getting execution %s, %s\n",
+			executionID,
+			err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Encountered an error getting execution %s, status code: %d\n",
+			executionID,
+			resp.StatusCode)
+	}
+
+	jsonMap, ok := execution.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf(ErrCodeNoSuchEntityException)
+	}
+
+	return jsonMap, nil
+}
+
+// SearchPipelineExecutions returns the executions of an application's
+// pipelines that match opts, newest first.
+func (m *GatewayClient) SearchPipelineExecutions(applicationName string,
+	opts *gate.ExecutionsControllerApiSearchForPipelineExecutionsByTriggerUsingGETOpts) ([]map[string]interface{}, error) {
+
+	executions, resp, err := m.ExecutionsControllerApi.SearchForPipelineExecutionsByTriggerUsingGET(m.Context,
+		applicationName,
+		opts)
+
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s", ErrCodeNoSuchEntityException)
+		}
+		return nil, fmt.Errorf("Encountered an error searching executions in application %s, %s\n",
+			applicationName,
+			err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Encountered an error searching executions in application %s, status code: %d\n",
+			applicationName,
+			resp.StatusCode)
+	}
+
+	result := make([]map[string]interface{}, 0, len(executions))
+	for _, e := range executions {
+		if execution, ok := e.(map[string]interface{}); ok {
+			result = append(result, execution)
+		}
+	}
+
+	return result, nil
+}
@@ -1,44 +1,95 @@
 package gateclient
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
 
+	"github.com/antihax/optional"
 	"github.com/mitchellh/mapstructure"
+	gate "github.com/spinnaker/spin/gateapi"
+)
+
+// PipelineKind selects the kind of pipeline config a call works on. Gate
+// stores pipelines and deployment strategies the same way, under different
+// endpoints.
+type PipelineKind struct {
+	noun string
+	path string
+}
+
+var (
+	KindPipeline = PipelineKind{noun: "pipeline", path: "pipelines"}
+	KindStrategy = PipelineKind{noun: "strategy", path: "strategies"}
 )
 
 func (m *GatewayClient) CreatePipeline(pipeline interface{}) error {
-	resp, err := m.PipelineControllerApi.SavePipelineUsingPOST(m.Context, pipeline, nil)
+	return m.CreatePipelineConfig(KindPipeline, pipeline)
+}
+
+func (m *GatewayClient) GetPipeline(applicationName, pipelineName string, dest interface{}) (map[string]interface{}, error) {
+	return m.GetPipelineConfig(KindPipeline, applicationName, pipelineName, dest)
+}
+
+func (m *GatewayClient) GetPipelines(applicationName string) ([]map[string]interface{}, error) {
+	return m.GetPipelineConfigs(KindPipeline, applicationName)
+}
+
+func (m *GatewayClient) UpdatePipeline(pipelineID string, pipeline interface{}) error {
+	return m.UpdatePipelineConfig(KindPipeline, pipelineID, pipeline)
+}
+
+func (m *GatewayClient) DeletePipeline(applicationName, pipelineName string) error {
+	return m.DeletePipelineConfig(KindPipeline, applicationName, pipelineName)
+}
+
+func (m *GatewayClient) CreatePipelineConfig(kind PipelineKind, config interface{}) error {
+	var resp *http.Response
+	var err error
+	if kind == KindPipeline {
+		resp, err = m.PipelineControllerApi.SavePipelineUsingPOST(m.Context, config, nil)
+	} else {
+		resp, err = m.gateRequest(http.MethodPost, "/"+kind.path, config)
+	}
 
 	if err != nil {
 		return err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Encountered an error saving pipeline, status code: %d\n", resp.StatusCode)
+		return fmt.Errorf("Encountered an error saving %s, status code: %d\n", kind.noun, resp.StatusCode)
 	}
 
 	return nil
 }
 
-func (m *GatewayClient) GetPipeline(applicationName, pipelineName string, dest interface{}) (map[string]interface{}, error) {
-	jsonMap, resp, err := m.ApplicationControllerApi.GetPipelineConfigUsingGET(m.Context,
-		applicationName,
-		pipelineName)
+func (m *GatewayClient) GetPipelineConfig(kind PipelineKind, applicationName, name string, dest interface{}) (map[string]interface{}, error) {
+	get := m.ApplicationControllerApi.GetPipelineConfigUsingGET
+	if kind == KindStrategy {
+		get = m.ApplicationControllerApi.GetStrategyConfigUsingGET
+	}
+
+	jsonMap, resp, err := get(m.Context, applicationName, name)
 
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			return jsonMap, fmt.Errorf("%s", ErrCodeNoSuchEntityException)
 		}
-		return jsonMap, fmt.Errorf("Encountered an error getting pipeline %s, %s\n",
-			pipelineName,
+		return jsonMap, fmt.Errorf("Encountered an error getting %s %s, %s\n",
+			kind.noun,
+			name,
 			err.Error())
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return jsonMap, fmt.Errorf("Encountered an error getting pipeline in pipeline %s with name %s, status code: %d\n",
+		return jsonMap, fmt.Errorf("Encountered an error getting %s in application %s with name %s, status code: %d\n",
+			kind.noun,
 			applicationName,
-			pipelineName,
+			name,
 			resp.StatusCode)
 	}
 
@@ -53,30 +104,158 @@ func (m *GatewayClient) GetPipeline(applicationName, pipelineName string, dest i
 	return jsonMap, nil
 }
 
-func (m *GatewayClient) UpdatePipeline(pipelineID string, pipeline interface{}) error {
-	_, resp, err := m.PipelineControllerApi.UpdatePipelineUsingPUT(m.Context, pipelineID, pipeline)
+// GetPipelineByID returns the current revision of the pipeline with the
+// given ID, taken from the pipeline config history.
+func (m *GatewayClient) GetPipelineByID(pipelineID string, dest interface{}) (map[string]interface{}, error) {
+	history, resp, err := m.PipelineConfigControllerApi.GetPipelineConfigHistoryUsingGET(m.Context,
+		pipelineID,
+		&gate.PipelineConfigControllerApiGetPipelineConfigHistoryUsingGETOpts{Limit: optional.NewInt32(1)})
+
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s", ErrCodeNoSuchEntityException)
+		}
+		return nil, fmt.Errorf("Encountered an error getting pipeline with id %s, %s\n",
+			pipelineID,
+			err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Encountered an error getting pipeline with id %s, status code: %d\n",
+			pipelineID,
+			resp.StatusCode)
+	}
+
+	if len(history) == 0 {
+		return nil, fmt.Errorf(ErrCodeNoSuchEntityException)
+	}
+
+	jsonMap, ok := history[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf(ErrCodeNoSuchEntityException)
+	}
+
+	if err := mapstructure.Decode(jsonMap, dest); err != nil {
+		return jsonMap, err
+	}
+
+	return jsonMap, nil
+}
+
+func (m *GatewayClient) GetPipelineConfigs(kind PipelineKind, applicationName string) ([]map[string]interface{}, error) {
+	list := m.ApplicationControllerApi.GetPipelineConfigsForApplicationUsingGET
+	if kind == KindStrategy {
+		list = m.ApplicationControllerApi.GetStrategyConfigsForApplicationUsingGET
+	}
+
+	configs, resp, err := list(m.Context, applicationName)
+
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s", ErrCodeNoSuchEntityException)
+		}
+		return nil, fmt.Errorf("Encountered an error listing %s in application %s, %s\n",
+			kind.path,
+			applicationName,
+			err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Encountered an error listing %s in application %s, status code: %d\n",
+			kind.path,
+			applicationName,
+			resp.StatusCode)
+	}
+
+	result := make([]map[string]interface{}, 0, len(configs))
+	for _, c := range configs {
+		if config, ok := c.(map[string]interface{}); ok {
+			result = append(result, config)
+		}
+	}
+
+	return result, nil
+}
+
+func (m *GatewayClient) UpdatePipelineConfig(kind PipelineKind, id string, config interface{}) error {
+	var resp *http.Response
+	var err error
+	if kind == KindPipeline {
+		_, resp, err = m.PipelineControllerApi.UpdatePipelineUsingPUT(m.Context, id, config)
+	} else {
+		resp, err = m.gateRequest(http.MethodPut, "/"+kind.path+"/"+url.PathEscape(id), config)
+	}
 
 	if err != nil {
 		return err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Encountered an error saving pipeline, status code: %d\n", resp.StatusCode)
+		return fmt.Errorf("Encountered an error saving %s, status code: %d\n", kind.noun, resp.StatusCode)
 	}
 
 	return nil
 }
 
-func (m *GatewayClient) DeletePipeline(applicationName, pipelineName string) error {
-	resp, err := m.PipelineControllerApi.DeletePipelineUsingDELETE(m.Context, applicationName, pipelineName)
+func (m *GatewayClient) DeletePipelineConfig(kind PipelineKind, applicationName, name string) error {
+	var resp *http.Response
+	var err error
+	if kind == KindPipeline {
+		resp, err = m.PipelineControllerApi.DeletePipelineUsingDELETE(m.Context, applicationName, name)
+	} else {
+		resp, err = m.gateRequest(http.MethodDelete,
+			"/"+kind.path+"/"+url.PathEscape(applicationName)+"/"+url.PathEscape(name), nil)
+	}
 
 	if err != nil {
 		return err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Encountered an error deleting pipeline, status code: %d\n", resp.StatusCode)
+		return fmt.Errorf("Encountered an error deleting %s, status code: %d\n", kind.noun, resp.StatusCode)
 	}
 
 	return nil
 }
+
+// gateRequest sends a JSON request to a Gate endpoint the generated API
+// client does not cover, such as /strategies. Like the generated client,
+// it returns an error for responses with a status of 300 or more.
+func (m *GatewayClient) gateRequest(method, path string, body interface{}) (*http.Response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(m.GateEndpoint(), "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(m.Context)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range m.defaultHeaders {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode >= 300 {
+		return resp, fmt.Errorf("%s %s: %s %s", method, path, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	return resp, nil
+}
@@ -1,18 +1,33 @@
 package gateclient
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
+	"github.com/antihax/optional"
 	"github.com/mitchellh/mapstructure"
+	gate "github.com/spinnaker/spin/gateapi"
 )
 
 const (
 	ErrCodeNoSuchEntityException = "NoSuchEntityException"
 )
 
-func (m *GatewayClient) CreatePipelineTemplate(template interface{}) error {
-	resp, err := m.PipelineTemplatesControllerApi.CreateUsingPOST(m.Context, template)
+// templateVersion selects a tagged version of a template; an empty tag
+// selects the latest version.
+func templateVersion(tag string) optional.String {
+	if tag == "" {
+		return optional.EmptyString()
+	}
+	return optional.NewString(tag)
+}
+
+func (m *GatewayClient) CreatePipelineTemplate(template interface{}, tag string) error {
+	_, resp, err := m.V2PipelineTemplatesControllerApi.CreateUsingPOST1(m.Context,
+		template,
+		&gate.V2PipelineTemplatesControllerApiCreateUsingPOST1Opts{Tag: templateVersion(tag)})
 	if err != nil {
 		return err
 	}
@@ -24,8 +39,10 @@ func (m *GatewayClient) CreatePipelineTemplate(template interface{}) error {
 	return nil
 }
 
-func (m *GatewayClient) GetPipelineTemplate(templateID string, dest interface{}) error {
-	successPayload, resp, err := m.PipelineTemplatesControllerApi.GetUsingGET(m.Context, templateID)
+func (m *GatewayClient) GetPipelineTemplate(templateID, tag string, dest interface{}) error {
+	successPayload, resp, err := m.V2PipelineTemplatesControllerApi.GetUsingGET2(m.Context,
+		templateID,
+		&gate.V2PipelineTemplatesControllerApiGetUsingGET2Opts{Tag: templateVersion(tag)})
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
 			return fmt.Errorf("%s", ErrCodeNoSuchEntityException)
@@ -53,8 +70,71 @@ func (m *GatewayClient) GetPipelineTemplate(templateID string, dest interface{})
 	return nil
 }
 
-func (m *GatewayClient) DeletePipelineTemplate(templateID string) error {
-	_, resp, err := m.PipelineTemplatesControllerApi.DeleteUsingDELETE(m.Context, templateID, nil)
+// GetPipelineTemplateVersions returns every version of a template, each
+// carrying its tag and digest.
+func (m *GatewayClient) GetPipelineTemplateVersions(templateID string) ([]map[string]interface{}, error) {
+	successPayload, resp, err := m.V2PipelineTemplatesControllerApi.ListVersionsUsingGET(m.Context, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Encountered an error listing versions of pipeline template %s, %s\n",
+			templateID,
+			err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Encountered an error listing versions of pipeline template %s, status code: %d\n",
+			templateID,
+			resp.StatusCode)
+	}
+
+	// Versions are grouped by template ID.
+	byID, _ := successPayload.(map[string]interface{})
+	raw, ok := byID[templateID].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf(ErrCodeNoSuchEntityException)
+	}
+
+	versions := make([]map[string]interface{}, 0, len(raw))
+	for _, v := range raw {
+		if version, ok := v.(map[string]interface{}); ok {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions, nil
+}
+
+// GetPipelineTemplateDependents returns the pipelines that use a template.
+func (m *GatewayClient) GetPipelineTemplateDependents(templateID string) ([]map[string]interface{}, error) {
+	dependents, resp, err := m.V2PipelineTemplatesControllerApi.ListPipelineTemplateDependentsUsingGET1(m.Context, templateID)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s", ErrCodeNoSuchEntityException)
+		}
+		return nil, fmt.Errorf("Encountered an error listing dependents of pipeline template %s, %s\n",
+			templateID,
+			err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Encountered an error listing dependents of pipeline template %s, status code: %d\n",
+			templateID,
+			resp.StatusCode)
+	}
+
+	result := make([]map[string]interface{}, 0, len(dependents))
+	for _, d := range dependents {
+		if dependent, ok := d.(map[string]interface{}); ok {
+			result = append(result, dependent)
+		}
+	}
+
+	return result, nil
+}
+
+func (m *GatewayClient) DeletePipelineTemplate(templateID, tag string) error {
+	_, resp, err := m.V2PipelineTemplatesControllerApi.DeleteUsingDELETE1(m.Context,
+		templateID,
+		&gate.V2PipelineTemplatesControllerApiDeleteUsingDELETE1Opts{Tag: templateVersion(tag)})
 	if err != nil {
 		return err
 	}
@@ -68,8 +148,11 @@ func (m *GatewayClient) DeletePipelineTemplate(templateID string) error {
 	return nil
 }
 
-func (m *GatewayClient) UpdatePipelineTemplate(templateID string, template interface{}) error {
-	resp, err := m.PipelineTemplatesControllerApi.UpdateUsingPOST(m.Context, templateID, template, nil)
+func (m *GatewayClient) UpdatePipelineTemplate(templateID, tag string, template interface{}) error {
+	_, resp, err := m.V2PipelineTemplatesControllerApi.UpdateUsingPOST1(m.Context,
+		templateID,
+		template,
+		&gate.V2PipelineTemplatesControllerApiUpdateUsingPOST1Opts{Tag: templateVersion(tag)})
 	if err != nil {
 		return err
 	}
@@ -82,3 +165,65 @@ func (m *GatewayClient) UpdatePipelineTemplate(templateID string, template inter
 
 	return nil
 }
+
+// TemplateValidationError holds the errors the template engine reported
+// for a pipeline template config.
+type TemplateValidationError struct {
+	Messages []string
+}
+
+func (e *TemplateValidationError) Error() string {
+	return fmt.Sprintf("pipeline template config is invalid: %s", strings.Join(e.Messages, "; "))
+}
+
+// PlanPipelineTemplate renders a v2 templated pipeline config into the
+// pipeline it produces. Errors the template engine finds in the config are
+// returned as a *TemplateValidationError.
+func (m *GatewayClient) PlanPipelineTemplate(config interface{}) (map[string]interface{}, error) {
+	pipeline, resp, err := m.V2PipelineTemplatesControllerApi.PlanUsingPOST(m.Context, config)
+	if err != nil {
+		if resp != nil && resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			if swaggerErr, ok := err.(gate.GenericSwaggerError); ok {
+				if messages := templateErrorMessages(swaggerErr.Body()); len(messages) > 0 {
+					return nil, &TemplateValidationError{Messages: messages}
+				}
+			}
+		}
+		return nil, fmt.Errorf("Encountered an error planning pipeline template config, %s\n", err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Encountered an error planning pipeline template config, status code: %d\n", resp.StatusCode)
+	}
+
+	return pipeline, nil
+}
+
+// templateErrorMessages extracts the errors from a template engine error
+// response, each with its location in the config when known.
+func templateErrorMessages(body []byte) []string {
+	var response struct {
+		Message string `json:"message"`
+		Errors  []struct {
+			Message  string `json:"message"`
+			Location string `json:"location"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+
+	var messages []string
+	for _, e := range response.Errors {
+		if e.Location != "" {
+			messages = append(messages, fmt.Sprintf("%s: %s", e.Location, e.Message))
+		} else {
+			messages = append(messages, e.Message)
+		}
+	}
+	if len(messages) == 0 && response.Message != "" {
+		messages = append(messages, response.Message)
+	}
+
+	return messages
+}
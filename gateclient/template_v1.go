@@ -0,0 +1,143 @@
+package gateclient
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/antihax/optional"
+	"github.com/mitchellh/mapstructure"
+	gate "github.com/spinnaker/spin/gateapi"
+)
+
+// The functions below manage Managed Pipeline Templates v1, which have no
+// tags and are served by the /pipelineTemplates endpoints.
+
+func (m *GatewayClient) CreatePipelineTemplateV1(template interface{}) error {
+	resp, err := m.PipelineTemplatesControllerApi.CreateUsingPOST(m.Context, template)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("Encountered an error saving template, status code: %d\n", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (m *GatewayClient) GetPipelineTemplateV1(templateID string, dest interface{}) error {
+	successPayload, resp, err := m.PipelineTemplatesControllerApi.GetUsingGET(m.Context, templateID)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%s", ErrCodeNoSuchEntityException)
+		}
+		return fmt.Errorf("Encountered an error getting pipeline template %s, %s\n",
+			templateID,
+			err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Encountered an error getting pipeline template %s, status code: %d\n",
+			templateID,
+			resp.StatusCode,
+		)
+	}
+
+	if successPayload == nil {
+		return fmt.Errorf(ErrCodeNoSuchEntityException)
+	}
+
+	if err := mapstructure.Decode(successPayload, dest); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResolvePipelineTemplateV1 fetches the template at source, which may be a
+// spinnaker:// reference or a URL the template is hosted at, with any
+// templates it inherits from merged in.
+func (m *GatewayClient) ResolvePipelineTemplateV1(source string) (map[string]interface{}, error) {
+	template, resp, err := m.PipelineTemplatesControllerApi.ResolveTemplatesUsingGET(m.Context, source, nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s", ErrCodeNoSuchEntityException)
+		}
+		return nil, fmt.Errorf("Encountered an error resolving pipeline template %s, %s\n",
+			source,
+			err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Encountered an error resolving pipeline template %s, status code: %d\n",
+			source,
+			resp.StatusCode)
+	}
+
+	if template == nil {
+		return nil, fmt.Errorf(ErrCodeNoSuchEntityException)
+	}
+
+	return template, nil
+}
+
+// GetPipelineTemplateDependentsV1 returns the pipelines that use a template,
+// directly or through templates that inherit from it.
+func (m *GatewayClient) GetPipelineTemplateDependentsV1(templateID string) ([]map[string]interface{}, error) {
+	dependents, resp, err := m.PipelineTemplatesControllerApi.ListPipelineTemplateDependentsUsingGET(m.Context,
+		templateID,
+		&gate.PipelineTemplatesControllerApiListPipelineTemplateDependentsUsingGETOpts{Recursive: optional.NewBool(true)})
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s", ErrCodeNoSuchEntityException)
+		}
+		return nil, fmt.Errorf("Encountered an error listing dependents of pipeline template %s, %s\n",
+			templateID,
+			err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Encountered an error listing dependents of pipeline template %s, status code: %d\n",
+			templateID,
+			resp.StatusCode)
+	}
+
+	result := make([]map[string]interface{}, 0, len(dependents))
+	for _, d := range dependents {
+		if dependent, ok := d.(map[string]interface{}); ok {
+			result = append(result, dependent)
+		}
+	}
+
+	return result, nil
+}
+
+func (m *GatewayClient) DeletePipelineTemplateV1(templateID string) error {
+	_, resp, err := m.PipelineTemplatesControllerApi.DeleteUsingDELETE(m.Context, templateID, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("Encountered an error deleting pipeline template %s, status code: %d\n",
+			templateID,
+			resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (m *GatewayClient) UpdatePipelineTemplateV1(templateID string, template interface{}) error {
+	resp, err := m.PipelineTemplatesControllerApi.UpdateUsingPOST(m.Context, templateID, template, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("Encountered an error updating pipeline template %s, status code: %d\n",
+			templateID,
+			resp.StatusCode)
+	}
+
+	return nil
+}
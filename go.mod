@@ -3,6 +3,7 @@ module github.com/rmullinnix461332/terraform-provider-spinnaker
 go 1.14
 
 require (
+	github.com/antihax/optional v1.0.0
 	github.com/ghodss/yaml v1.0.0
 	github.com/hashicorp/go-getter v1.5.3 // indirect
 	github.com/hashicorp/go-plugin v1.4.10 // indirect
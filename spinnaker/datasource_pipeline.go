@@ -1,6 +1,9 @@
 package spinnaker
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -9,24 +12,296 @@ func datasourcePipeline() *schema.Resource {
 		Schema: map[string]*schema.Schema{
 			"application": {
 				Type:         schema.TypeString,
-				ForceNew:     true,
-				Required:     true,
+				Optional:     true,
+				Computed:     true,
+				RequiredWith: []string{"name"},
 				ValidateFunc: validateApplicationName,
 			},
 			"name": {
-				Type:     schema.TypeString,
-				ForceNew: true,
-				Required: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				RequiredWith: []string{"application"},
+				ExactlyOneOf: []string{"name", "pipeline_id"},
+			},
+			"pipeline_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"name", "pipeline_id"},
 			},
 			"pipeline": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
-			"pipeline_id": {
+			"pipeline_format": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"disabled": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"locked": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ui": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"allow_unlock_ui": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"description": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"parameters": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"label": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"description": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"default": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"required": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"options": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"stages": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"ref_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"requisite_stage_ref_ids": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"triggers": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"enabled": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"settings": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"update_ts": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"last_modified_by": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
 		},
-		Read: resourcePipelineRead,
+		Read: datasourcePipelineRead,
+	}
+}
+
+func datasourcePipelineRead(data *schema.ResourceData, meta interface{}) error {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+
+	var p pipelineRead
+	var jsonMap map[string]interface{}
+	var err error
+	if pipelineID, ok := data.GetOk("pipeline_id"); ok {
+		jsonMap, err = client.GetPipelineByID(pipelineID.(string), &p)
+	} else {
+		jsonMap, err = client.GetPipeline(data.Get("application").(string), data.Get("name").(string), &p)
+	}
+	if err != nil {
+		return err
+	}
+	pipelineName := p.Name
+
+	// Read these before the pipeline attribute is encoded below, which
+	// strips the managed fields from jsonMap.
+	attributes := map[string]interface{}{
+		"application":      p.Application,
+		"name":             p.Name,
+		"pipeline_id":      p.ID,
+		"pipeline_format":  pipelineFormatJSON,
+		"disabled":         jsonMap["disabled"] == true,
+		"locked":           flattenPipelineLocked(jsonMap["locked"]),
+		"parameters":       flattenPipelineParameters(jsonMap["parameterConfig"]),
+		"stages":           flattenPipelineStages(jsonMap["stages"]),
+		"update_ts":        stringValue(jsonMap["updateTs"]),
+		"last_modified_by": stringValue(jsonMap["lastModifiedBy"]),
+	}
+	if attributes["triggers"], err = flattenPipelineTriggerSettings(jsonMap["triggers"]); err != nil {
+		return err
+	}
+
+	if attributes["pipeline"], err = editAndEncodePipeline(jsonMap); err != nil {
+		return err
 	}
+
+	for key, value := range attributes {
+		if err := data.Set(key, value); err != nil {
+			return fmt.Errorf("Could not set %s for pipeline %s: %s", key, pipelineName, err)
+		}
+	}
+
+	data.SetId(p.ID)
+
+	return nil
+}
+
+// flattenPipelineParameters converts a pipeline's parameterConfig to the
+// parameters attribute. Defaults and options are rendered as strings.
+func flattenPipelineParameters(v interface{}) []interface{} {
+	raw, _ := v.([]interface{})
+	parameters := make([]interface{}, 0, len(raw))
+
+	for _, r := range raw {
+		param, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		options := make([]interface{}, 0)
+		if values, _ := param["options"].([]interface{}); len(values) > 0 {
+			for _, o := range values {
+				if option, ok := o.(map[string]interface{}); ok {
+					options = append(options, stringValue(option["value"]))
+				}
+			}
+		}
+
+		required, _ := param["required"].(bool)
+		parameters = append(parameters, map[string]interface{}{
+			"name":        stringValue(param["name"]),
+			"label":       stringValue(param["label"]),
+			"description": stringValue(param["description"]),
+			"default":     stringValue(param["default"]),
+			"required":    required,
+			"options":     options,
+		})
+	}
+
+	return parameters
+}
+
+// flattenPipelineStages converts a pipeline's stages to the stages
+// attribute, in the order Spinnaker returns them.
+func flattenPipelineStages(v interface{}) []interface{} {
+	raw, _ := v.([]interface{})
+	stages := make([]interface{}, 0, len(raw))
+
+	for _, r := range raw {
+		stage, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		requisites := make([]interface{}, 0)
+		if ids, _ := stage["requisiteStageRefIds"].([]interface{}); len(ids) > 0 {
+			for _, id := range ids {
+				requisites = append(requisites, refIDString(id))
+			}
+		}
+
+		stages = append(stages, map[string]interface{}{
+			"name":                    stringValue(stage["name"]),
+			"type":                    stringValue(stage["type"]),
+			"ref_id":                  refIDString(stage["refId"]),
+			"requisite_stage_ref_ids": requisites,
+		})
+	}
+
+	return stages
+}
+
+// flattenPipelineTriggerSettings converts a pipeline's triggers to the
+// triggers attribute. The type specific fields of each trigger are kept as
+// JSON in settings, since they differ between trigger types.
+func flattenPipelineTriggerSettings(v interface{}) ([]interface{}, error) {
+	raw, _ := v.([]interface{})
+	triggers := make([]interface{}, 0, len(raw))
+
+	for _, r := range raw {
+		trigger, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		settings := make(map[string]interface{}, len(trigger))
+		for k, value := range trigger {
+			if k != "type" && k != "enabled" {
+				settings[k] = value
+			}
+		}
+		encoded, err := json.Marshal(settings)
+		if err != nil {
+			return nil, err
+		}
+
+		enabled, ok := trigger["enabled"].(bool)
+		if !ok {
+			enabled = true
+		}
+		triggers = append(triggers, map[string]interface{}{
+			"type":     stringValue(trigger["type"]),
+			"enabled":  enabled,
+			"settings": string(encoded),
+		})
+	}
+
+	return triggers, nil
 }
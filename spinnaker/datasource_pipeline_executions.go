@@ -0,0 +1,155 @@
+package spinnaker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/antihax/optional"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	gate "github.com/spinnaker/spin/gateapi"
+)
+
+func datasourcePipelineExecutions() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"application": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateApplicationName,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"statuses": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(append(executionActiveStatuses, executionTerminalStatuses...), false),
+				},
+			},
+			"triggered_after": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"triggered_before": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"limit": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      10,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"executions": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"execution_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"status": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"start_time": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"end_time": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"trigger_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"trigger_user": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+		Read: datasourcePipelineExecutionsRead,
+	}
+}
+
+func datasourcePipelineExecutionsRead(data *schema.ResourceData, meta interface{}) error {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+	applicationName := data.Get("application").(string)
+	pipelineName := data.Get("name").(string)
+
+	opts := &gate.ExecutionsControllerApiSearchForPipelineExecutionsByTriggerUsingGETOpts{
+		PipelineName: optional.NewString(pipelineName),
+		Size:         optional.NewInt32(int32(data.Get("limit").(int))),
+		Expand:       optional.NewBool(false),
+	}
+
+	if v, ok := data.GetOk("statuses"); ok {
+		var statuses []string
+		for _, s := range v.([]interface{}) {
+			statuses = append(statuses, s.(string))
+		}
+		opts.Statuses = optional.NewString(strings.Join(statuses, ","))
+	}
+	if v, ok := data.GetOk("triggered_after"); ok {
+		after, _ := time.Parse(time.RFC3339, v.(string))
+		opts.TriggerTimeStartBoundary = optional.NewInt64(after.UnixNano() / int64(time.Millisecond))
+	}
+	if v, ok := data.GetOk("triggered_before"); ok {
+		before, _ := time.Parse(time.RFC3339, v.(string))
+		opts.TriggerTimeEndBoundary = optional.NewInt64(before.UnixNano() / int64(time.Millisecond))
+	}
+
+	executions, err := client.SearchPipelineExecutions(applicationName, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := data.Set("executions", flattenPipelineExecutions(executions)); err != nil {
+		return fmt.Errorf("Could not set executions for pipeline %s: %s", pipelineName, err)
+	}
+
+	data.SetId(fmt.Sprintf("%s/%s", applicationName, pipelineName))
+
+	return nil
+}
+
+// flattenPipelineExecutions converts executions to the executions
+// attribute. Times are rendered in RFC 3339 and are empty until set.
+func flattenPipelineExecutions(executions []map[string]interface{}) []interface{} {
+	result := make([]interface{}, 0, len(executions))
+
+	for _, execution := range executions {
+		trigger, _ := execution["trigger"].(map[string]interface{})
+
+		result = append(result, map[string]interface{}{
+			"execution_id": stringValue(execution["id"]),
+			"status":       stringValue(execution["status"]),
+			"start_time":   executionTime(execution["startTime"]),
+			"end_time":     executionTime(execution["endTime"]),
+			"trigger_type": stringValue(trigger["type"]),
+			"trigger_user": stringValue(trigger["user"]),
+		})
+	}
+
+	return result
+}
+
+func executionTime(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return formatUpdateTs(stringValue(v))
+}
@@ -0,0 +1,47 @@
+package spinnaker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenPipelineExecutions(t *testing.T) {
+	executions := []map[string]interface{}{
+		{
+			"id":        "01EXEC",
+			"status":    "SUCCEEDED",
+			"startTime": float64(1700000000000),
+			"endTime":   float64(1700000060000),
+			"trigger":   map[string]interface{}{"type": "manual", "user": "alice@example.com"},
+		},
+		{
+			"id":        "02EXEC",
+			"status":    "RUNNING",
+			"startTime": float64(1700000120000),
+			"trigger":   map[string]interface{}{"type": "cron", "user": "[anonymous]"},
+		},
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{
+			"execution_id": "01EXEC",
+			"status":       "SUCCEEDED",
+			"start_time":   "2023-11-14T22:13:20Z",
+			"end_time":     "2023-11-14T22:14:20Z",
+			"trigger_type": "manual",
+			"trigger_user": "alice@example.com",
+		},
+		map[string]interface{}{
+			"execution_id": "02EXEC",
+			"status":       "RUNNING",
+			"start_time":   "2023-11-14T22:15:20Z",
+			"end_time":     "",
+			"trigger_type": "cron",
+			"trigger_user": "[anonymous]",
+		},
+	}
+
+	if result := flattenPipelineExecutions(executions); !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected executions %v, got %v", expected, result)
+	}
+}
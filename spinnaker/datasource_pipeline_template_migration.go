@@ -0,0 +1,101 @@
+package spinnaker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func datasourcePipelineTemplateMigration() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"template": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				AtLeastOneOf: []string{"template", "pipeline_config"},
+			},
+			"pipeline_config": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"converted_template": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"converted_pipeline_config": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"warnings": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+		Read: datasourcePipelineTemplateMigrationRead,
+	}
+}
+
+func datasourcePipelineTemplateMigrationRead(data *schema.ResourceData, meta interface{}) error {
+	var ids []string
+	warnings := make([]interface{}, 0)
+
+	for _, c := range []struct {
+		key     string
+		convert func(map[string]interface{}) (map[string]interface{}, []string)
+		id      func(map[string]interface{}) string
+	}{
+		{"template", convertPipelineTemplateV1, func(t map[string]interface{}) string {
+			return stringValue(t["id"])
+		}},
+		{"pipeline_config", convertTemplateConfigV1, func(config map[string]interface{}) string {
+			pipeline, _ := config["pipeline"].(map[string]interface{})
+			return fmt.Sprintf("%s/%s", stringValue(pipeline["application"]), stringValue(pipeline["name"]))
+		}},
+	} {
+		v := data.Get(c.key).(string)
+		if v == "" {
+			if err := data.Set("converted_"+c.key, ""); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var doc map[string]interface{}
+		if err := yaml.Unmarshal([]byte(v), &doc); err != nil {
+			return fmt.Errorf("Error decoding %s: %s", c.key, err)
+		}
+		if templateSchema(doc) != templateSchemaV1 {
+			return fmt.Errorf("%s must be a v1 document with schema %q", c.key, templateSchemaV1)
+		}
+		ids = append(ids, c.id(doc))
+
+		converted, problems := c.convert(doc)
+		for _, p := range problems {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", c.key, p))
+		}
+
+		jsonContent, err := json.Marshal(converted)
+		if err != nil {
+			return err
+		}
+		raw, err := yaml.JSONToYAML(jsonContent)
+		if err != nil {
+			return err
+		}
+		if err := data.Set("converted_"+c.key, string(raw)); err != nil {
+			return fmt.Errorf("Could not set converted_%s: %s", c.key, err)
+		}
+	}
+
+	if err := data.Set("warnings", warnings); err != nil {
+		return fmt.Errorf("Could not set warnings: %s", err)
+	}
+
+	data.SetId(strings.Join(ids, ","))
+
+	return nil
+}
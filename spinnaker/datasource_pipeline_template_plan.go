@@ -0,0 +1,100 @@
+package spinnaker
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/rmullinnix461332/terraform-provider-spinnaker/gateclient"
+)
+
+func datasourcePipelineTemplatePlan() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"application": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateApplicationName,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "terraform-plan",
+			},
+			"template_reference": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringMatch(pipelineTemplateURLPattern, "must be a spinnaker://<template id>[:<tag>] URL"),
+			},
+			"variables": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsJSON,
+			},
+			"pipeline": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"errors": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+		Read: datasourcePipelineTemplatePlanRead,
+	}
+}
+
+func datasourcePipelineTemplatePlanRead(data *schema.ResourceData, meta interface{}) error {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+	applicationName := data.Get("application").(string)
+	pipelineName := data.Get("name").(string)
+	reference := data.Get("template_reference").(string)
+
+	variables := make(map[string]interface{})
+	if v := data.Get("variables").(string); v != "" {
+		if err := json.Unmarshal([]byte(v), &variables); err != nil {
+			return fmt.Errorf("variables must be a JSON object: %s", err)
+		}
+	}
+
+	config := map[string]interface{}{
+		"schema":      "v2",
+		"type":        "templatedPipeline",
+		"application": applicationName,
+		"name":        pipelineName,
+		"template":    pipelineTemplateReference(reference),
+		"variables":   variables,
+	}
+
+	var pipeline string
+	errors := make([]interface{}, 0)
+
+	rendered, err := client.PlanPipelineTemplate(config)
+	if validationErr, ok := err.(*gateclient.TemplateValidationError); ok {
+		for _, message := range validationErr.Messages {
+			errors = append(errors, message)
+		}
+	} else if err != nil {
+		return err
+	} else {
+		encoded, err := json.MarshalIndent(rendered, "", "  ")
+		if err != nil {
+			return err
+		}
+		pipeline = string(encoded)
+	}
+
+	if err := data.Set("pipeline", pipeline); err != nil {
+		return fmt.Errorf("Could not set pipeline for plan of %s: %s", reference, err)
+	}
+	if err := data.Set("errors", errors); err != nil {
+		return fmt.Errorf("Could not set errors for plan of %s: %s", reference, err)
+	}
+
+	data.SetId(fmt.Sprintf("%s/%s/%s", applicationName, pipelineName, reference))
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package spinnaker
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func datasourcePipelineTemplateVersions() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateTemplateName,
+			},
+			"versions": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"tag": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"digest": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"url": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"update_ts": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"last_modified_by": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+		Read: datasourcePipelineTemplateVersionsRead,
+	}
+}
+
+func datasourcePipelineTemplateVersionsRead(data *schema.ResourceData, meta interface{}) error {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+	templateName := data.Get("name").(string)
+
+	versions, err := client.GetPipelineTemplateVersions(templateName)
+	if err != nil {
+		return err
+	}
+
+	if err := data.Set("versions", flattenPipelineTemplateVersions(templateName, versions)); err != nil {
+		return fmt.Errorf("Could not set versions for template %s: %s", templateName, err)
+	}
+
+	data.SetId(templateName)
+
+	return nil
+}
+
+// flattenPipelineTemplateVersions converts template versions to the
+// versions attribute. The untagged latest version has an empty tag.
+func flattenPipelineTemplateVersions(templateName string, versions []map[string]interface{}) []interface{} {
+	result := make([]interface{}, 0, len(versions))
+
+	for _, version := range versions {
+		tag := stringValue(version["tag"])
+		result = append(result, map[string]interface{}{
+			"tag":              tag,
+			"digest":           stringValue(version["digest"]),
+			"url":              pipelineTemplateURL(templateName, tag),
+			"update_ts":        stringValue(version["updateTs"]),
+			"last_modified_by": stringValue(version["lastModifiedBy"]),
+		})
+	}
+
+	return result
+}
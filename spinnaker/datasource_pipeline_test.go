@@ -0,0 +1,55 @@
+package spinnaker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenPipelineDetails(t *testing.T) {
+	pipeline := decodeTestPipeline(t, `{
+		"parameterConfig": [
+			{"name": "env", "label": "Environment", "default": "dev", "required": true,
+			 "hasOptions": true, "options": [{"value": "dev"}, {"value": "prod"}]}
+		],
+		"stages": [
+			{"name": "Bake", "refId": "1", "type": "bake"},
+			{"name": "Deploy", "refId": 2, "type": "deploy", "requisiteStageRefIds": ["1"]}
+		],
+		"triggers": [{"type": "cron", "cronExpression": "0 0 12 * * ?"}]
+	}`)
+
+	parameters := flattenPipelineParameters(pipeline["parameterConfig"])
+	expectedParameters := []interface{}{
+		map[string]interface{}{
+			"name":        "env",
+			"label":       "Environment",
+			"description": "",
+			"default":     "dev",
+			"required":    true,
+			"options":     []interface{}{"dev", "prod"},
+		},
+	}
+	if !reflect.DeepEqual(parameters, expectedParameters) {
+		t.Fatalf("expected parameters %v, got %v", expectedParameters, parameters)
+	}
+
+	stages := flattenPipelineStages(pipeline["stages"])
+	expectedStages := []interface{}{
+		map[string]interface{}{"name": "Bake", "type": "bake", "ref_id": "1", "requisite_stage_ref_ids": []interface{}{}},
+		map[string]interface{}{"name": "Deploy", "type": "deploy", "ref_id": "2", "requisite_stage_ref_ids": []interface{}{"1"}},
+	}
+	if !reflect.DeepEqual(stages, expectedStages) {
+		t.Fatalf("expected stages %v, got %v", expectedStages, stages)
+	}
+
+	triggers, err := flattenPipelineTriggerSettings(pipeline["triggers"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedTriggers := []interface{}{
+		map[string]interface{}{"type": "cron", "enabled": true, "settings": `{"cronExpression":"0 0 12 * * ?"}`},
+	}
+	if !reflect.DeepEqual(triggers, expectedTriggers) {
+		t.Fatalf("expected triggers %v, got %v", expectedTriggers, triggers)
+	}
+}
@@ -0,0 +1,115 @@
+package spinnaker
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func datasourcePipelines() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"application": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateApplicationName,
+			},
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"pipelines": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"pipeline_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"disabled": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"trigger_types": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"stage_count": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+		Read: datasourcePipelinesRead,
+	}
+}
+
+func datasourcePipelinesRead(data *schema.ResourceData, meta interface{}) error {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+	applicationName := data.Get("application").(string)
+
+	var nameRegex *regexp.Regexp
+	if v, ok := data.GetOk("name_regex"); ok {
+		nameRegex = regexp.MustCompile(v.(string))
+	}
+
+	pipelines, err := client.GetPipelines(applicationName)
+	if err != nil {
+		return err
+	}
+
+	if err := data.Set("pipelines", flattenPipelineSummaries(pipelines, nameRegex)); err != nil {
+		return fmt.Errorf("Could not set pipelines for application %s: %s", applicationName, err)
+	}
+
+	data.SetId(applicationName)
+
+	return nil
+}
+
+// flattenPipelineSummaries summarizes the pipelines whose name matches
+// nameRegex, or every pipeline when it is nil.
+func flattenPipelineSummaries(pipelines []map[string]interface{}, nameRegex *regexp.Regexp) []interface{} {
+	result := make([]interface{}, 0, len(pipelines))
+	for _, p := range pipelines {
+		name, _ := p["name"].(string)
+		if nameRegex != nil && !nameRegex.MatchString(name) {
+			continue
+		}
+
+		id, _ := p["id"].(string)
+		disabled, _ := p["disabled"].(bool)
+		stages, _ := p["stages"].([]interface{})
+
+		triggers, _ := p["triggers"].([]interface{})
+		triggerTypes := make([]interface{}, 0, len(triggers))
+		for _, t := range triggers {
+			trigger, _ := t.(map[string]interface{})
+			if triggerType, ok := trigger["type"].(string); ok {
+				triggerTypes = append(triggerTypes, triggerType)
+			}
+		}
+
+		result = append(result, map[string]interface{}{
+			"name":          name,
+			"pipeline_id":   id,
+			"disabled":      disabled,
+			"trigger_types": triggerTypes,
+			"stage_count":   len(stages),
+		})
+	}
+
+	return result
+}
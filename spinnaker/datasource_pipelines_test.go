@@ -0,0 +1,47 @@
+package spinnaker
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestFlattenPipelineSummaries(t *testing.T) {
+	pipelines := []map[string]interface{}{
+		decodeTestPipeline(t, `{
+			"id": "a1",
+			"name": "Deploy Prod",
+			"disabled": true,
+			"stages": [{"refId": "1"}, {"refId": "2"}],
+			"triggers": [{"type": "cron"}, {"enabled": true}, {"type": "git"}]
+		}`),
+		decodeTestPipeline(t, `{"id": "b2", "name": "Deploy Staging"}`),
+		decodeTestPipeline(t, `{"id": "c3", "name": "Cleanup"}`),
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{
+			"name":          "Deploy Prod",
+			"pipeline_id":   "a1",
+			"disabled":      true,
+			"trigger_types": []interface{}{"cron", "git"},
+			"stage_count":   2,
+		},
+		map[string]interface{}{
+			"name":          "Deploy Staging",
+			"pipeline_id":   "b2",
+			"disabled":      false,
+			"trigger_types": []interface{}{},
+			"stage_count":   0,
+		},
+	}
+
+	result := flattenPipelineSummaries(pipelines, regexp.MustCompile(`^Deploy`))
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected pipelines %v, got %v", expected, result)
+	}
+
+	if result := flattenPipelineSummaries(pipelines, nil); len(result) != 3 {
+		t.Fatalf("expected every pipeline without name_regex, got %v", result)
+	}
+}
@@ -0,0 +1,239 @@
+package spinnaker
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// knownExpressionHelpers are the helper functions Orca registers for
+// pipeline expressions. Anything else called as #name(...) is most likely
+// a typo that will only surface when the stage runs.
+var knownExpressionHelpers = map[string]bool{
+	"alphanumerical":                true,
+	"cfServiceKey":                  true,
+	"currentStage":                  true,
+	"deployedServerGroups":          true,
+	"filterArtifactsByType":         true,
+	"fromBase64":                    true,
+	"fromUrl":                       true,
+	"jsonFromUrl":                   true,
+	"judgement":                     true,
+	"judgment":                      true,
+	"manifestLabelValue":            true,
+	"pipelineId":                    true,
+	"pipelineIdOrNull":              true,
+	"propertiesFromUrl":             true,
+	"readJson":                      true,
+	"readYaml":                      true,
+	"resolveArtifact":               true,
+	"stage":                         true,
+	"stageByRefId":                  true,
+	"stageExists":                   true,
+	"toBase64":                      true,
+	"toBoolean":                     true,
+	"toFloat":                       true,
+	"toInt":                         true,
+	"toJson":                        true,
+	"triggerResolvedArtifact":       true,
+	"triggerResolvedArtifactOrNull": true,
+	"yamlFromUrl":                   true,
+}
+
+var (
+	expressionHelperRegexp    = regexp.MustCompile(`#([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+	expressionParameterRegexp = regexp.MustCompile(`(?:^|[^.\w])parameters(?:\.([A-Za-z_][A-Za-z0-9_]*)|\[\s*['"]([^'"]+)['"]\s*\])`)
+)
+
+// validateExpressions scans every string in a pipeline document for
+// ${...} expressions and fails on any that are malformed, e.g. with
+// unbalanced delimiters, since those only surface when the stage runs.
+func validateExpressions(doc interface{}) error {
+	errs, _ := scanExpressions(doc, nil)
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid pipeline expressions:\n  - %s", strings.Join(errs, "\n  - "))
+}
+
+// expressionWarnings returns a warning for every call to an unknown helper
+// and, unless parameters is nil, every reference to a parameter that is not
+// in the declared set.
+func expressionWarnings(doc interface{}, parameters map[string]bool) []string {
+	_, warnings := scanExpressions(doc, parameters)
+	return warnings
+}
+
+// validatePipelineExpressions is the ValidateFunc of attributes holding a
+// pipeline document. Its findings are warnings, so they show up in the plan
+// without failing it. Documents that cannot be decoded are left to the
+// checks that decode them.
+func validatePipelineExpressions(v interface{}, k string) ([]string, []error) {
+	doc, err := decodePipeline(v.(string))
+	if err != nil {
+		return nil, nil
+	}
+	return expressionWarnings(doc, nil), nil
+}
+
+func scanExpressions(doc interface{}, parameters map[string]bool) (errs []string, warnings []string) {
+	walkStrings(doc, "$", func(path, value string) {
+		for _, expr := range extractExpressions(value) {
+			if expr.err != "" {
+				errs = append(errs, fmt.Sprintf("%s: %s in %q", path, expr.err, value))
+				continue
+			}
+
+			for _, m := range expressionHelperRegexp.FindAllStringSubmatch(expr.body, -1) {
+				if !knownExpressionHelpers[m[1]] {
+					warnings = append(warnings, fmt.Sprintf("%s: unknown expression helper #%s", path, m[1]))
+				}
+			}
+
+			if parameters == nil {
+				continue
+			}
+			for _, name := range expressionParameterNames(expr.body) {
+				if !parameters[name] {
+					warnings = append(warnings, fmt.Sprintf("%s: parameter %q is not declared in parameterConfig", path, name))
+				}
+			}
+		}
+	})
+
+	return
+}
+
+// walkStrings calls fn for every string value in doc, with the JSON path
+// leading to it. Map keys are visited in sorted order so output is stable.
+func walkStrings(doc interface{}, path string, fn func(path, value string)) {
+	switch v := doc.(type) {
+	case string:
+		fn(path, v)
+	case []interface{}:
+		for i, item := range v {
+			walkStrings(item, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkStrings(v[k], jsonPathChild(path, k), fn)
+		}
+	}
+}
+
+var plainJSONPathKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func jsonPathChild(path, key string) string {
+	if plainJSONPathKey.MatchString(key) {
+		return path + "." + key
+	}
+	return fmt.Sprintf("%s[%q]", path, key)
+}
+
+type expression struct {
+	body string
+	err  string
+}
+
+// extractExpressions returns the body of every ${...} in s. Braces,
+// brackets and parentheses have to nest properly and quoted strings are
+// skipped, mirroring how the SpEL template parser finds the closing brace.
+func extractExpressions(s string) []expression {
+	var exprs []expression
+
+	for i := 0; i < len(s); {
+		start := strings.Index(s[i:], "${")
+		if start < 0 {
+			break
+		}
+		start += i + 2
+
+		end, errMsg := matchExpressionEnd(s, start)
+		if errMsg != "" {
+			exprs = append(exprs, expression{err: errMsg})
+			break
+		}
+
+		exprs = append(exprs, expression{body: s[start:end]})
+		i = end + 1
+	}
+
+	return exprs
+}
+
+func matchExpressionEnd(s string, start int) (int, string) {
+	closers := map[byte]byte{'(': ')', '[': ']', '{': '}'}
+	var stack []byte
+	var quote byte
+
+	for i := start; i < len(s); i++ {
+		c := s[i]
+
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"':
+			quote = c
+		case '(', '[', '{':
+			stack = append(stack, closers[c])
+		case ')', ']', '}':
+			if len(stack) == 0 {
+				if c == '}' {
+					if strings.TrimSpace(s[start:i]) == "" {
+						return 0, "empty expression"
+					}
+					return i, ""
+				}
+				return 0, fmt.Sprintf("unexpected '%c'", c)
+			}
+			if stack[len(stack)-1] != c {
+				return 0, fmt.Sprintf("expected '%c' but found '%c'", stack[len(stack)-1], c)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if quote != 0 {
+		return 0, "unterminated string literal"
+	}
+	return 0, "unterminated expression, missing '}'"
+}
+
+func expressionParameterNames(body string) []string {
+	var names []string
+	for _, m := range expressionParameterRegexp.FindAllStringSubmatch(body, -1) {
+		if m[1] != "" {
+			names = append(names, m[1])
+		} else {
+			names = append(names, m[2])
+		}
+	}
+	return names
+}
+
+// declaredParameters returns the names in a pipeline's parameterConfig.
+func declaredParameters(pipeline map[string]interface{}) map[string]bool {
+	declared := make(map[string]bool)
+
+	params, _ := pipeline["parameterConfig"].([]interface{})
+	for _, p := range params {
+		if param, ok := p.(map[string]interface{}); ok {
+			if name, ok := param["name"].(string); ok {
+				declared[name] = true
+			}
+		}
+	}
+
+	return declared
+}
@@ -0,0 +1,82 @@
+package spinnaker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanExpressions(t *testing.T) {
+	pipe := decodeTestPipeline(t, `{
+		"parameterConfig": [{"name": "version"}],
+		"stages": [{
+			"name": "Build",
+			"parameters": {
+				"BRANCH": "${ parameters.version }",
+				"REPO": "${parameters['repository']}",
+				"STATUS": "${#stage('Bake')['status'] == 'SUCCEEDED'}",
+				"TYPO": "${#stag('Bake').context}",
+				"MAP": "${ {'a': 1}['a'] }",
+				"BROKEN": "${ #toJson(parameters.version }",
+				"OPEN": "prefix ${ trigger.user",
+				"LITERAL": "no expression }"
+			}
+		}]
+	}`)
+
+	errs, warnings := scanExpressions(pipe, declaredParameters(pipe))
+
+	expectedErrs := []string{
+		`$.stages[0].parameters.BROKEN: expected ')' but found '}'`,
+		`$.stages[0].parameters.OPEN: unterminated expression`,
+	}
+	if len(errs) != len(expectedErrs) {
+		t.Fatalf("expected %d errors, got %q", len(expectedErrs), errs)
+	}
+	for i, e := range expectedErrs {
+		if !strings.HasPrefix(errs[i], e) {
+			t.Fatalf("expected error %q, got %q", e, errs[i])
+		}
+	}
+
+	expectedWarnings := []string{
+		`$.stages[0].parameters.REPO: parameter "repository" is not declared in parameterConfig`,
+		`$.stages[0].parameters.TYPO: unknown expression helper #stag`,
+	}
+	if strings.Join(warnings, "\n") != strings.Join(expectedWarnings, "\n") {
+		t.Fatalf("expected warnings %q, got %q", expectedWarnings, warnings)
+	}
+}
+
+func TestScanExpressionsWithoutParameters(t *testing.T) {
+	config := decodeTestPipeline(t, `{"variables": {"image": "${parameters.image}"}}`)
+
+	errs, warnings := scanExpressions(config, nil)
+	if len(errs) != 0 || len(warnings) != 0 {
+		t.Fatalf("expected no findings, got errors %q and warnings %q", errs, warnings)
+	}
+}
+
+func TestValidateExpressions(t *testing.T) {
+	if err := validateExpressions(decodeTestPipeline(t, `{"stages": [{"name": "${ trigger.user }"}]}`)); err != nil {
+		t.Fatalf("expected well-formed expressions to pass, got %s", err)
+	}
+
+	err := validateExpressions(decodeTestPipeline(t, `{"stages": [{"name": "${ #toJson(trigger }"}]}`))
+	if err == nil || !strings.Contains(err.Error(), `$.stages[0].name: expected ')' but found '}'`) {
+		t.Fatalf("expected an error for the malformed expression, got %v", err)
+	}
+}
+
+func TestValidatePipelineExpressions(t *testing.T) {
+	warnings, errs := validatePipelineExpressions(`{"stages": [{"name": "${#stag('Bake').name}"}]}`, "pipeline")
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+	if len(warnings) != 1 || warnings[0] != `$.stages[0].name: unknown expression helper #stag` {
+		t.Fatalf("expected a warning for the unknown helper, got %q", warnings)
+	}
+
+	if warnings, errs := validatePipelineExpressions(`{"stages": [`, "pipeline"); len(warnings) != 0 || len(errs) != 0 {
+		t.Fatalf("expected undecodable documents to be skipped, got warnings %q and errors %v", warnings, errs)
+	}
+}
@@ -0,0 +1,109 @@
+package spinnaker
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// templateConfigManagedKeys are set by Spinnaker when a config is saved.
+var templateConfigManagedKeys = []string{
+	"index",
+	"lastModifiedBy",
+	"updateTs",
+}
+
+// templateConfigIgnoredKeys are managed through other attributes of
+// spinnaker_pipeline_template_config, so they are not compared.
+var templateConfigIgnoredKeys = []string{
+	"locked",
+	"name",
+	"type",
+}
+
+// pipelineConfigPlain has the fields of PipelineConfig without its JSON
+// methods.
+type pipelineConfigPlain PipelineConfig
+
+// pipelineConfigKeys returns the JSON keys of the modelled fields of
+// PipelineConfig.
+func pipelineConfigKeys() []string {
+	t := reflect.TypeOf(PipelineConfig{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if key != "" && key != "-" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (c PipelineConfig) MarshalJSON() ([]byte, error) {
+	known, err := json.Marshal(pipelineConfigPlain(c))
+	if err != nil || len(c.Extra) == 0 {
+		return known, err
+	}
+
+	var merged map[string]interface{}
+	if err := json.Unmarshal(known, &merged); err != nil {
+		return nil, err
+	}
+	for k, v := range c.Extra {
+		if _, ok := merged[k]; !ok {
+			merged[k] = v
+		}
+	}
+
+	return json.Marshal(merged)
+}
+
+func (c *PipelineConfig) UnmarshalJSON(b []byte) error {
+	var plain pipelineConfigPlain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+
+	var extra map[string]interface{}
+	if err := json.Unmarshal(b, &extra); err != nil {
+		return err
+	}
+	deleteKeys(extra, pipelineConfigKeys())
+	if len(extra) > 0 {
+		plain.Extra = extra
+	} else {
+		plain.Extra = nil
+	}
+
+	*c = PipelineConfig(plain)
+	return nil
+}
+
+// normalizeTemplateConfig drops the fields of a decoded config that are
+// managed elsewhere, defaulted or empty, so two configs can be compared
+// field by field.
+func normalizeTemplateConfig(config map[string]interface{}) {
+	deleteKeys(config, templateConfigManagedKeys)
+	deleteKeys(config, templateConfigIgnoredKeys)
+	deleteKeys(config, pipelineUIOnlyKeys)
+	stripDefaults(config, pipelineDefaults)
+
+	for k, v := range config {
+		switch t := v.(type) {
+		case nil:
+			delete(config, k)
+		case string:
+			if t == "" {
+				delete(config, k)
+			}
+		case []interface{}:
+			if len(t) == 0 {
+				delete(config, k)
+			}
+		case map[string]interface{}:
+			if len(t) == 0 {
+				delete(config, k)
+			}
+		}
+	}
+}
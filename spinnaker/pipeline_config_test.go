@@ -0,0 +1,156 @@
+package spinnaker
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+func TestPipelineConfigKeepsUnknownFields(t *testing.T) {
+	in := `{
+		"schema": "v2",
+		"application": "app",
+		"name": "Deploy",
+		"template": {"reference": "spinnaker://deploy"},
+		"exclude": ["smoke-test"],
+		"expectedArtifacts": [{"id": "image"}],
+		"keepWaitingPipelines": true
+	}`
+
+	var p PipelineConfig
+	if err := json.Unmarshal([]byte(in), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	wantExtra := map[string]interface{}{
+		"exclude":              []interface{}{"smoke-test"},
+		"expectedArtifacts":    []interface{}{map[string]interface{}{"id": "image"}},
+		"keepWaitingPipelines": true,
+	}
+	if !reflect.DeepEqual(p.Extra, wantExtra) {
+		t.Errorf("Extra = %v, want %v", p.Extra, wantExtra)
+	}
+
+	out, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want map[string]interface{}
+	json.Unmarshal(out, &got)
+	json.Unmarshal([]byte(in), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %s, want %s", out, in)
+	}
+}
+
+func TestPipelineConfigDecodeRemain(t *testing.T) {
+	in := map[string]interface{}{
+		"name":              "Deploy",
+		"parameterConfig":   []map[string]interface{}{{"name": "env"}},
+		"expectedArtifacts": []interface{}{},
+		"updateTs":          "1700000000000",
+	}
+
+	var p PipelineConfig
+	if err := mapstructure.Decode(in, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Parameters) != 1 {
+		t.Errorf("Parameters = %v", p.Parameters)
+	}
+	if _, ok := p.Extra["expectedArtifacts"]; !ok {
+		t.Errorf("expectedArtifacts not kept: %v", p.Extra)
+	}
+	if _, ok := p.Extra["name"]; ok {
+		t.Errorf("name should not be in Extra: %v", p.Extra)
+	}
+}
+
+func TestAreRoughlyEqualJSON(t *testing.T) {
+	user := `
+schema: v2
+application: app
+name: Deploy
+template:
+  reference: spinnaker://deploy
+variables:
+  replicas: 3
+exclude:
+  - smoke-test
+`
+
+	cases := []struct {
+		name   string
+		server string
+		equal  bool
+	}{
+		{
+			name: "managed and default fields",
+			server: `
+schema: v2
+id: 7a2c9b8e
+application: app
+name: Deploy
+type: templatedPipeline
+index: 2
+updateTs: "1700000000000"
+lastModifiedBy: someone
+keepWaitingPipelines: false
+limitConcurrent: true
+notifications: []
+template:
+  reference: spinnaker://deploy
+variables:
+  replicas: 3
+exclude:
+  - smoke-test
+`,
+			equal: true,
+		},
+		{
+			name: "exclude changed",
+			server: `
+schema: v2
+application: app
+template:
+  reference: spinnaker://deploy
+variables:
+  replicas: 3
+exclude:
+  - deploy
+`,
+			equal: false,
+		},
+		{
+			name: "unknown field added",
+			server: `
+schema: v2
+application: app
+template:
+  reference: spinnaker://deploy
+variables:
+  replicas: 3
+exclude:
+  - smoke-test
+expectedArtifacts:
+  - id: image
+`,
+			equal: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			equal, err := areRoughlyEqualJSON(c.server, user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if equal != c.equal {
+				t.Errorf("areRoughlyEqualJSON = %v, want %v", equal, c.equal)
+			}
+		})
+	}
+}
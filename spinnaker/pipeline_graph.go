@@ -0,0 +1,182 @@
+package spinnaker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type stageNode struct {
+	name       string
+	refID      string
+	requisites []string
+}
+
+// validateStageGraph checks the stage dependency graph described by the
+// refId / requisiteStageRefIds of each stage. Spinnaker accepts broken
+// graphs on save and only fails when the pipeline runs, so we catch
+// duplicate refIds, dangling references, cycles and unreachable stages
+// at plan time.
+func validateStageGraph(pipeline map[string]interface{}) error {
+	rawStages, ok := pipeline["stages"].([]interface{})
+	if !ok || len(rawStages) == 0 {
+		return nil
+	}
+
+	var problems []string
+	nodes := make(map[string]*stageNode)
+	var order []*stageNode
+
+	for i, raw := range rawStages {
+		stage, ok := raw.(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("stages[%d] is not an object", i))
+			continue
+		}
+
+		node := &stageNode{
+			name:  stageDisplayName(stage, i),
+			refID: refIDString(stage["refId"]),
+		}
+		if node.refID == "" {
+			problems = append(problems, fmt.Sprintf("stage %s has no refId", node.name))
+			continue
+		}
+		if requisites, ok := stage["requisiteStageRefIds"].([]interface{}); ok {
+			for _, r := range requisites {
+				node.requisites = append(node.requisites, refIDString(r))
+			}
+		}
+
+		if existing, ok := nodes[node.refID]; ok {
+			problems = append(problems, fmt.Sprintf("stages %s and %s share refId %q",
+				existing.name, node.name, node.refID))
+			continue
+		}
+		nodes[node.refID] = node
+		order = append(order, node)
+	}
+
+	broken := make(map[string]bool)
+	for _, node := range order {
+		for _, r := range node.requisites {
+			if _, ok := nodes[r]; !ok {
+				problems = append(problems, fmt.Sprintf("stage %s depends on unknown refId %q", node.name, r))
+				broken[node.refID] = true
+			}
+		}
+	}
+
+	for _, cycle := range findStageCycles(order, nodes) {
+		names := make([]string, 0, len(cycle))
+		for _, refID := range cycle {
+			names = append(names, nodes[refID].name)
+			broken[refID] = true
+		}
+		problems = append(problems, fmt.Sprintf("stages form a cycle: %s", strings.Join(names, " -> ")))
+	}
+
+	// A stage runs once all of its requisites have completed, so anything
+	// downstream of a dangling reference or a cycle will never start.
+	reachable := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, node := range order {
+			if reachable[node.refID] || broken[node.refID] {
+				continue
+			}
+			ready := true
+			for _, r := range node.requisites {
+				if !reachable[r] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				reachable[node.refID] = true
+				changed = true
+			}
+		}
+	}
+	for _, node := range order {
+		if !reachable[node.refID] && !broken[node.refID] {
+			problems = append(problems, fmt.Sprintf("stage %s is unreachable", node.name))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid pipeline stage graph:\n  - %s", strings.Join(problems, "\n  - "))
+}
+
+// findStageCycles returns each cycle in the graph as a list of refIds,
+// starting and ending on the same stage.
+func findStageCycles(order []*stageNode, nodes map[string]*stageNode) [][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	state := make(map[string]int)
+	var stack []string
+	var cycles [][]string
+
+	var visit func(refID string)
+	visit = func(refID string) {
+		state[refID] = visiting
+		stack = append(stack, refID)
+
+		requisites := append([]string(nil), nodes[refID].requisites...)
+		sort.Strings(requisites)
+		for _, r := range requisites {
+			if _, ok := nodes[r]; !ok {
+				continue
+			}
+			switch state[r] {
+			case unvisited:
+				visit(r)
+			case visiting:
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == r {
+						cycle := append([]string(nil), stack[i:]...)
+						cycles = append(cycles, append(cycle, r))
+						break
+					}
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[refID] = done
+	}
+
+	for _, node := range order {
+		if state[node.refID] == unvisited {
+			visit(node.refID)
+		}
+	}
+
+	return cycles
+}
+
+func stageDisplayName(stage map[string]interface{}, index int) string {
+	if name, ok := stage["name"].(string); ok && name != "" {
+		return fmt.Sprintf("%q", name)
+	}
+	return fmt.Sprintf("stages[%d]", index)
+}
+
+// refIDString normalizes refIds, which Deck writes as strings but older
+// pipelines sometimes carry as numbers.
+func refIDString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
@@ -0,0 +1,65 @@
+package spinnaker
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateStageGraph(t *testing.T) {
+	valid := []string{
+		`{"stages": []}`,
+		`{"stages": [{"name": "Bake", "refId": "1", "requisiteStageRefIds": []}]}`,
+		`{"stages": [
+			{"name": "Bake", "refId": "1", "requisiteStageRefIds": []},
+			{"name": "Deploy Dev", "refId": "2", "requisiteStageRefIds": ["1"]},
+			{"name": "Deploy Prod", "refId": "3", "requisiteStageRefIds": ["1", "2"]}
+		]}`,
+		`{"stages": [{"name": "Bake", "refId": 1}, {"name": "Deploy", "refId": 2, "requisiteStageRefIds": [1]}]}`,
+	}
+	for _, v := range valid {
+		if err := validateStageGraph(decodeTestPipeline(t, v)); err != nil {
+			t.Fatalf("%s should be a valid stage graph: %s", v, err)
+		}
+	}
+
+	invalid := map[string][]string{
+		`{"stages": [{"name": "Bake", "refId": "1"}, {"name": "Deploy", "refId": "1"}]}`: {
+			`"Bake" and "Deploy" share refId "1"`,
+		},
+		`{"stages": [{"name": "Bake", "refId": "1"}, {"name": "Deploy", "refId": "2", "requisiteStageRefIds": ["9"]}]}`: {
+			`"Deploy" depends on unknown refId "9"`,
+		},
+		`{"stages": [
+			{"name": "Bake", "refId": "1"},
+			{"name": "Deploy Dev", "refId": "2", "requisiteStageRefIds": ["1", "3"]},
+			{"name": "Deploy Prod", "refId": "3", "requisiteStageRefIds": ["2"]},
+			{"name": "Notify", "refId": "4", "requisiteStageRefIds": ["3"]}
+		]}`: {
+			`cycle: "Deploy Dev" -> "Deploy Prod" -> "Deploy Dev"`,
+			`"Notify" is unreachable`,
+		},
+		`{"stages": [{"name": "Bake"}]}`: {
+			`"Bake" has no refId`,
+		},
+	}
+	for v, messages := range invalid {
+		err := validateStageGraph(decodeTestPipeline(t, v))
+		if err == nil {
+			t.Fatalf("%s should be an invalid stage graph", v)
+		}
+		for _, m := range messages {
+			if !strings.Contains(err.Error(), m) {
+				t.Fatalf("expected error for %s to contain %q, got: %s", v, m, err)
+			}
+		}
+	}
+}
+
+func decodeTestPipeline(t *testing.T, s string) map[string]interface{} {
+	var pipe map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &pipe); err != nil {
+		t.Fatalf("could not decode %s: %s", s, err)
+	}
+	return pipe
+}
@@ -0,0 +1,99 @@
+package spinnaker
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// lockedSchema is the `locked` block shared by the pipeline resources. It
+// maps to the pipeline's "locked" object, which makes Deck show the
+// pipeline as read-only.
+func lockedSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"ui": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     true,
+					Description: "Prevent the pipeline from being edited in Deck",
+				},
+				"allow_unlock_ui": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "Allow users to unlock the pipeline from Deck",
+				},
+				"description": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "",
+					Description: "Reason shown in Deck for the pipeline being locked",
+				},
+			},
+		},
+	}
+}
+
+// expandPipelineLocked returns the "locked" object for the pipeline
+// payload, or nil when no locked block is configured.
+func expandPipelineLocked(data *schema.ResourceData) map[string]interface{} {
+	return expandLockedBlocks(data.Get("locked").([]interface{}))
+}
+
+// expandLockedBlocks is expandPipelineLocked for a value of the locked
+// attribute.
+func expandLockedBlocks(blocks []interface{}) map[string]interface{} {
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+
+	block := blocks[0].(map[string]interface{})
+	locked := map[string]interface{}{
+		"ui":            block["ui"].(bool),
+		"allowUnlockUi": block["allow_unlock_ui"].(bool),
+	}
+	if description := block["description"].(string); description != "" {
+		locked["description"] = description
+	}
+
+	return locked
+}
+
+// flattenPipelineLocked converts a pipeline's "locked" object to the
+// locked block. Pipelines that are not locked have no block.
+func flattenPipelineLocked(v interface{}) []interface{} {
+	locked, ok := v.(map[string]interface{})
+	if !ok {
+		return []interface{}{}
+	}
+
+	ui, _ := locked["ui"].(bool)
+	allowUnlockUi, _ := locked["allowUnlockUi"].(bool)
+	description, _ := locked["description"].(string)
+
+	if !ui && !allowUnlockUi && description == "" {
+		return []interface{}{}
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			"ui":              ui,
+			"allow_unlock_ui": allowUnlockUi,
+			"description":     description,
+		},
+	}
+}
+
+// applyPipelineLocked sets or clears the "locked" object of a pipeline
+// payload from the locked block. The block is authoritative, so a
+// "locked" key written into the pipeline JSON itself is dropped.
+func applyPipelineLocked(data *schema.ResourceData, pipeline map[string]interface{}) {
+	if locked := expandPipelineLocked(data); locked != nil {
+		pipeline["locked"] = locked
+	} else {
+		delete(pipeline, "locked")
+	}
+}
@@ -0,0 +1,152 @@
+package spinnaker
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// pipelineManagedKeys are set by Spinnaker or handled by other schema
+// attributes, so they never take part in a comparison.
+var pipelineManagedKeys = []string{
+	"application",
+	"disabled",
+	"id",
+	"index",
+	"lastModifiedBy",
+	"locked",
+	"name",
+	"updateTs",
+}
+
+// pipelineUIOnlyKeys are written by Deck for its own bookkeeping and have
+// no effect on how a pipeline runs.
+var pipelineUIOnlyKeys = []string{
+	"isNew",
+}
+
+// pipelineDefaults are the values Front50 and Deck fill in when a pipeline
+// is saved without them. A key set to its default is treated as absent.
+var pipelineDefaults = map[string]interface{}{
+	"keepWaitingPipelines": false,
+	"limitConcurrent":      true,
+	"spelEvaluator":        "v4",
+}
+
+// stageDefaults are the per-stage equivalent of pipelineDefaults.
+var stageDefaults = map[string]interface{}{
+	"completeOtherBranchesThenFail": false,
+	"continuePipeline":              false,
+	"failPipeline":                  true,
+}
+
+// normalizePipeline strips managed, UI-only and defaulted fields from a
+// decoded pipeline and sorts arrays whose order Spinnaker does not preserve
+// or care about, so two pipelines that behave the same compare equal. The
+// map is edited in place and returned.
+func normalizePipeline(pipeline map[string]interface{}) map[string]interface{} {
+	deleteKeys(pipeline, pipelineManagedKeys)
+	deleteKeys(pipeline, pipelineUIOnlyKeys)
+	stripDefaults(pipeline, pipelineDefaults)
+
+	if stages, ok := pipeline["stages"].([]interface{}); ok {
+		for _, s := range stages {
+			if stage, ok := s.(map[string]interface{}); ok {
+				deleteKeys(stage, pipelineUIOnlyKeys)
+				stripDefaults(stage, stageDefaults)
+				stripEmptyArrays(stage, "requisiteStageRefIds")
+			}
+		}
+		// Execution order comes from requisiteStageRefIds, not the array
+		// order, which Deck rewrites whenever stages are moved around.
+		sort.SliceStable(stages, func(i, j int) bool {
+			return lessRefID(stageRefID(stages[i]), stageRefID(stages[j]))
+		})
+	}
+
+	for _, key := range []string{"triggers", "notifications", "expectedArtifacts"} {
+		if items, ok := pipeline[key].([]interface{}); ok {
+			for _, item := range items {
+				if m, ok := item.(map[string]interface{}); ok {
+					deleteKeys(m, pipelineUIOnlyKeys)
+				}
+			}
+			sortByCanonicalJSON(items)
+		}
+	}
+
+	stripEmptyArrays(pipeline, "triggers", "notifications", "parameterConfig", "expectedArtifacts", "stages")
+
+	return pipeline
+}
+
+func stripDefaults(m map[string]interface{}, defaults map[string]interface{}) {
+	for key, def := range defaults {
+		if v, ok := m[key]; ok && reflect.DeepEqual(v, def) {
+			delete(m, key)
+		}
+	}
+}
+
+func stripEmptyArrays(m map[string]interface{}, keys ...string) {
+	for _, key := range keys {
+		v, ok := m[key]
+		if !ok {
+			continue
+		}
+		if items, ok := v.([]interface{}); v == nil || (ok && len(items) == 0) {
+			delete(m, key)
+		}
+	}
+}
+
+func deleteKeys(m map[string]interface{}, keys []string) {
+	for _, key := range keys {
+		delete(m, key)
+	}
+}
+
+func stageRefID(stage interface{}) string {
+	if m, ok := stage.(map[string]interface{}); ok {
+		return refIDString(m["refId"])
+	}
+	return ""
+}
+
+// lessRefID orders numeric refIds numerically and everything else
+// lexically, so "2" sorts before "10".
+func lessRefID(a, b string) bool {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return na < nb
+	case errA == nil:
+		return true
+	case errB == nil:
+		return false
+	}
+	return a < b
+}
+
+func sortByCanonicalJSON(items []interface{}) {
+	keys := make([]string, len(items))
+	for i, item := range items {
+		b, _ := json.Marshal(item)
+		keys[i] = string(b)
+	}
+	sort.Sort(byKey{items: items, keys: keys})
+}
+
+type byKey struct {
+	items []interface{}
+	keys  []string
+}
+
+func (s byKey) Len() int           { return len(s.items) }
+func (s byKey) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s byKey) Swap(i, j int) {
+	s.items[i], s.items[j] = s.items[j], s.items[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
@@ -0,0 +1,106 @@
+package spinnaker
+
+import (
+	"testing"
+)
+
+func TestDecodeEditAndEncodePipelineDefaults(t *testing.T) {
+	user := `{
+		"stages": [
+			{"name": "Deploy", "refId": "10", "requisiteStageRefIds": ["2"], "type": "deploy"},
+			{"name": "Bake", "refId": "2", "type": "bake"}
+		],
+		"triggers": [
+			{"type": "git", "enabled": true, "branch": "main"},
+			{"type": "cron", "enabled": true, "cronExpression": "0 0 12 * * ?"}
+		]
+	}`
+
+	server := `{
+		"application": "app",
+		"name": "Deploy",
+		"id": "7a2c9b8e",
+		"index": 3,
+		"updateTs": "1700000000000",
+		"lastModifiedBy": "someone@example.com",
+		"keepWaitingPipelines": false,
+		"limitConcurrent": true,
+		"spelEvaluator": "v4",
+		"isNew": true,
+		"notifications": [],
+		"parameterConfig": [],
+		"stages": [
+			{"name": "Bake", "refId": "2", "requisiteStageRefIds": [], "type": "bake", "failPipeline": true, "isNew": true},
+			{"name": "Deploy", "refId": "10", "requisiteStageRefIds": ["2"], "type": "deploy", "continuePipeline": false}
+		],
+		"triggers": [
+			{"type": "cron", "enabled": true, "cronExpression": "0 0 12 * * ?"},
+			{"type": "git", "enabled": true, "branch": "main"}
+		]
+	}`
+
+	editedUser, err := decodeEditAndEncodePipeline(user)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	editedServer, err := decodeEditAndEncodePipeline(server)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if editedUser != editedServer {
+		t.Fatalf("expected pipelines to be equal after normalization:\n%s\n%s", editedUser, editedServer)
+	}
+}
+
+func TestDecodeEditAndEncodePipelineRealChanges(t *testing.T) {
+	changed := map[string]string{
+		`{"limitConcurrent": true}`:                           `{"limitConcurrent": false}`,
+		`{"stages": [{"refId": "1", "failPipeline": true}]}`:  `{"stages": [{"refId": "1", "failPipeline": false}]}`,
+		`{"parameterConfig": [{"name": "a"}, {"name": "b"}]}`: `{"parameterConfig": [{"name": "b"}, {"name": "a"}]}`,
+	}
+
+	for old, new := range changed {
+		editedOld, err := decodeEditAndEncodePipeline(old)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		editedNew, err := decodeEditAndEncodePipeline(new)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if editedOld == editedNew {
+			t.Fatalf("expected %s and %s to differ after normalization", old, new)
+		}
+	}
+}
+
+func TestPipelineStateFuncYAML(t *testing.T) {
+	json := `{"stages": [{"name": "Bake", "refId": "1", "type": "bake"}], "limitConcurrent": false}`
+	yaml := `
+limitConcurrent: false
+stages:
+  - name: Bake
+    refId: "1"
+    type: bake
+`
+
+	if f := detectPipelineFormat(json); f != pipelineFormatJSON {
+		t.Fatalf("expected json format, got %s", f)
+	}
+	if f := detectPipelineFormat(yaml); f != pipelineFormatYAML {
+		t.Fatalf("expected yaml format, got %s", f)
+	}
+
+	if !pipelineDiffSuppressFunc("pipeline", json, yaml, nil) {
+		t.Fatalf("expected equivalent JSON and YAML pipelines to suppress the diff")
+	}
+
+	stored := pipelineStateFunc(yaml)
+	if detectPipelineFormat(stored) != pipelineFormatYAML {
+		t.Fatalf("expected YAML pipeline to be stored as YAML, got:\n%s", stored)
+	}
+	if !pipelineDiffSuppressFunc("pipeline", stored, json, nil) {
+		t.Fatalf("expected stored YAML to be equivalent to the JSON pipeline, got:\n%s", stored)
+	}
+}
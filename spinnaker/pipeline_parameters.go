@@ -0,0 +1,140 @@
+package spinnaker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// validatePipelineParameters cross-checks the parameterConfig of a
+// pipeline against the ${parameters.*} references in its expressions.
+// Referencing an undeclared parameter is an error since it always
+// evaluates to null.
+func validatePipelineParameters(pipeline map[string]interface{}) error {
+	errs, _ := checkPipelineParameters(pipeline)
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid pipeline parameters:\n  - %s", strings.Join(errs, "\n  - "))
+}
+
+// validatePipelineParameterUsage is a ValidateFunc that warns about
+// declared parameters that are never used, and required parameters without
+// a default on a pipeline that has automated triggers.
+func validatePipelineParameterUsage(v interface{}, k string) ([]string, []error) {
+	pipeline, err := decodePipeline(v.(string))
+	if err != nil {
+		return nil, nil
+	}
+	_, warnings := checkPipelineParameters(pipeline)
+	return warnings, nil
+}
+
+func checkPipelineParameters(pipeline map[string]interface{}) (errs []string, warnings []string) {
+	declared := declaredParameters(pipeline)
+	used := usedParameters(pipeline)
+
+	usedNames := make([]string, 0, len(used))
+	for name := range used {
+		usedNames = append(usedNames, name)
+	}
+	sort.Strings(usedNames)
+
+	for _, name := range usedNames {
+		if !declared[name] {
+			errs = append(errs, fmt.Sprintf("parameter %q is used at %s but not declared in parameterConfig",
+				name, strings.Join(used[name], ", ")))
+		}
+	}
+
+	declaredNames := make([]string, 0, len(declared))
+	for name := range declared {
+		declaredNames = append(declaredNames, name)
+	}
+	sort.Strings(declaredNames)
+
+	for _, name := range declaredNames {
+		if _, ok := used[name]; !ok {
+			warnings = append(warnings, fmt.Sprintf("parameter %q is declared but never used", name))
+		}
+	}
+
+	warnings = append(warnings, checkTriggeredParameterDefaults(pipeline)...)
+
+	return
+}
+
+// checkTriggeredParameterDefaults warns about required parameters without
+// a default when the pipeline can be started by a trigger, since automated
+// triggers do not supply parameter values.
+func checkTriggeredParameterDefaults(pipeline map[string]interface{}) (warnings []string) {
+	if !hasAutomatedTriggers(pipeline) {
+		return nil
+	}
+
+	params, _ := pipeline["parameterConfig"].([]interface{})
+	for _, p := range params {
+		param, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if required, _ := param["required"].(bool); !required {
+			continue
+		}
+		if parameterHasDefault(param) {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("required parameter %q has no default but the pipeline has automated triggers",
+			param["name"]))
+	}
+
+	return
+}
+
+// usedParameters maps each parameter referenced by an expression to the
+// JSON paths that reference it.
+func usedParameters(doc interface{}) map[string][]string {
+	used := make(map[string][]string)
+
+	walkStrings(doc, "$", func(path, value string) {
+		for _, expr := range extractExpressions(value) {
+			if expr.err != "" {
+				continue
+			}
+			for _, name := range expressionParameterNames(expr.body) {
+				used[name] = append(used[name], path)
+			}
+		}
+	})
+
+	return used
+}
+
+func hasAutomatedTriggers(pipeline map[string]interface{}) bool {
+	triggers, _ := pipeline["triggers"].([]interface{})
+	for _, t := range triggers {
+		trigger, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if enabled, ok := trigger["enabled"].(bool); !ok || enabled {
+			return true
+		}
+	}
+	return false
+}
+
+func parameterHasDefault(param map[string]interface{}) bool {
+	for _, key := range []string{"default", "defaultValue"} {
+		switch v := param[key].(type) {
+		case nil:
+		case string:
+			if v != "" {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
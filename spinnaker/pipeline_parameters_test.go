@@ -0,0 +1,85 @@
+package spinnaker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckPipelineParameters(t *testing.T) {
+	pipe := decodeTestPipeline(t, `{
+		"parameterConfig": [
+			{"name": "version", "required": true, "default": ""},
+			{"name": "region", "required": true, "default": "us-east-1"},
+			{"name": "unused"}
+		],
+		"triggers": [{"type": "cron", "enabled": true, "cronExpression": "0 0 * * * ?"}],
+		"stages": [{
+			"name": "Deploy",
+			"refId": "1",
+			"region": "${parameters.region}",
+			"version": "${ trigger.parameters.version }",
+			"account": "${parameters['account']}"
+		}]
+	}`)
+
+	errs, warnings := checkPipelineParameters(pipe)
+
+	if len(errs) != 1 || !strings.Contains(errs[0], `parameter "account" is used at $.stages[0].account`) {
+		t.Fatalf("expected undeclared account parameter error, got %q", errs)
+	}
+
+	expectedWarnings := []string{
+		`parameter "unused" is declared but never used`,
+		`parameter "version" is declared but never used`,
+		`required parameter "version" has no default but the pipeline has automated triggers`,
+	}
+	if strings.Join(warnings, "\n") != strings.Join(expectedWarnings, "\n") {
+		t.Fatalf("expected warnings %q, got %q", expectedWarnings, warnings)
+	}
+}
+
+func TestCheckPipelineParametersDisabledTriggers(t *testing.T) {
+	pipe := decodeTestPipeline(t, `{
+		"parameterConfig": [{"name": "version", "required": true}],
+		"triggers": [{"type": "git", "enabled": false}],
+		"stages": [{"name": "Build", "refId": "1", "branch": "${parameters.version}"}]
+	}`)
+
+	errs, warnings := checkPipelineParameters(pipe)
+	if len(errs) != 0 || len(warnings) != 0 {
+		t.Fatalf("expected no findings, got errors %q and warnings %q", errs, warnings)
+	}
+}
+
+func TestCheckPipelineParametersMemberAccess(t *testing.T) {
+	pipe := decodeTestPipeline(t, `{
+		"parameterConfig": [{"name": "region"}],
+		"stages": [{
+			"name": "Deploy",
+			"refId": "1",
+			"region": "${parameters.region}",
+			"version": "${trigger.parameters.version}",
+			"image": "${#stage('Build').context.parameters.image}",
+			"tag": "${ #stage('Build')['context'].parameters['tag'] }"
+		}]
+	}`)
+
+	errs, warnings := checkPipelineParameters(pipe)
+	if len(errs) != 0 || len(warnings) != 0 {
+		t.Fatalf("expected no findings, got errors %q and warnings %q", errs, warnings)
+	}
+}
+
+func TestValidatePipelineParameterUsage(t *testing.T) {
+	warnings, errs := validatePipelineParameterUsage(`{
+		"parameterConfig": [{"name": "unused"}],
+		"stages": [{"name": "Deploy", "refId": "1", "account": "${parameters.account}"}]
+	}`, "pipeline")
+
+	if len(errs) != 0 {
+		t.Fatalf("expected undeclared parameters to be left to CustomizeDiff, got %v", errs)
+	}
+	if len(warnings) != 1 || warnings[0] != `parameter "unused" is declared but never used` {
+		t.Fatalf("expected a warning for the unused parameter, got %q", warnings)
+	}
+}
@@ -0,0 +1,175 @@
+package spinnaker
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/rmullinnix461332/terraform-provider-spinnaker/gateclient"
+)
+
+// pipelineRefKey marks a reference to another pipeline by name inside a
+// pipeline definition, e.g.
+//
+//	"pipeline": {"$pipelineRef": {"application": "app", "name": "Deploy"}}
+//
+// References are replaced by the target pipeline's ID before the pipeline
+// is saved, and IDs are turned back into references when it is read, so
+// the same definition works against Spinnakers where the IDs differ.
+const pipelineRefKey = "$pipelineRef"
+
+type pipelineRef struct {
+	application string
+	name        string
+}
+
+func (r pipelineRef) toJSON() map[string]interface{} {
+	return map[string]interface{}{
+		pipelineRefKey: map[string]interface{}{
+			"application": r.application,
+			"name":        r.name,
+		},
+	}
+}
+
+// parsePipelineRef reports whether v is a pipeline reference, and fails if
+// it is one but is malformed.
+func parsePipelineRef(v interface{}) (pipelineRef, bool, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return pipelineRef{}, false, nil
+	}
+	inner, ok := m[pipelineRefKey]
+	if !ok {
+		return pipelineRef{}, false, nil
+	}
+
+	if len(m) != 1 {
+		return pipelineRef{}, true, fmt.Errorf("%s must be the only key in its object", pipelineRefKey)
+	}
+	fields, ok := inner.(map[string]interface{})
+	if !ok {
+		return pipelineRef{}, true, fmt.Errorf("%s must be an object with application and name", pipelineRefKey)
+	}
+	application, _ := fields["application"].(string)
+	name, _ := fields["name"].(string)
+	if application == "" || name == "" {
+		return pipelineRef{}, true, fmt.Errorf("%s requires both application and name", pipelineRefKey)
+	}
+
+	return pipelineRef{application: application, name: name}, true, nil
+}
+
+// rewritePipelineRefs returns a copy of doc with every pipeline reference
+// replaced by the result of fn.
+func rewritePipelineRefs(doc interface{}, fn func(ref pipelineRef) (interface{}, error)) (interface{}, error) {
+	ref, isRef, err := parsePipelineRef(doc)
+	if err != nil {
+		return nil, err
+	}
+	if isRef {
+		return fn(ref)
+	}
+
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		rewritten := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			if rewritten[k], err = rewritePipelineRefs(item, fn); err != nil {
+				return nil, err
+			}
+		}
+		return rewritten, nil
+	case []interface{}:
+		rewritten := make([]interface{}, len(v))
+		for i, item := range v {
+			if rewritten[i], err = rewritePipelineRefs(item, fn); err != nil {
+				return nil, err
+			}
+		}
+		return rewritten, nil
+	}
+
+	return doc, nil
+}
+
+// collectPipelineRefs returns every pipeline reference in doc.
+func collectPipelineRefs(doc interface{}) ([]pipelineRef, error) {
+	var refs []pipelineRef
+	_, err := rewritePipelineRefs(doc, func(ref pipelineRef) (interface{}, error) {
+		refs = append(refs, ref)
+		return nil, nil
+	})
+	return refs, err
+}
+
+// resolvePipelineRefs replaces every pipeline reference in a pipeline with
+// the ID of the pipeline it names.
+func resolvePipelineRefs(pipeline map[string]interface{}, client *gateclient.GatewayClient) (map[string]interface{}, error) {
+	ids := make(map[pipelineRef]string)
+
+	resolved, err := rewritePipelineRefs(pipeline, func(ref pipelineRef) (interface{}, error) {
+		if id, ok := ids[ref]; ok {
+			return id, nil
+		}
+
+		var p pipelineRead
+		if _, err := client.GetPipeline(ref.application, ref.name, &p); err != nil {
+			if err.Error() == gateclient.ErrCodeNoSuchEntityException {
+				return nil, fmt.Errorf("%s to pipeline %q in application %q: pipeline does not exist",
+					pipelineRefKey, ref.name, ref.application)
+			}
+			return nil, err
+		}
+
+		ids[ref] = p.ID
+		return p.ID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resolved.(map[string]interface{}), nil
+}
+
+// restorePipelineRefs turns the IDs of the referenced pipelines back into
+// references in a pipeline read from Spinnaker. refs are the references in
+// the pipeline as last configured; any that no longer resolve are left as
+// IDs so the difference shows up in the plan.
+func restorePipelineRefs(pipeline map[string]interface{}, refs []pipelineRef, client *gateclient.GatewayClient) (map[string]interface{}, error) {
+	if len(refs) == 0 {
+		return pipeline, nil
+	}
+
+	byID := make(map[string]pipelineRef)
+	for _, ref := range refs {
+		var p pipelineRead
+		if _, err := client.GetPipeline(ref.application, ref.name, &p); err != nil {
+			if err.Error() == gateclient.ErrCodeNoSuchEntityException {
+				log.Printf("[WARN] referenced pipeline %q in application %q no longer exists", ref.name, ref.application)
+				continue
+			}
+			return nil, err
+		}
+		byID[p.ID] = ref
+	}
+
+	return replacePipelineIDs(pipeline, byID).(map[string]interface{}), nil
+}
+
+func replacePipelineIDs(doc interface{}, byID map[string]pipelineRef) interface{} {
+	switch v := doc.(type) {
+	case string:
+		if ref, ok := byID[v]; ok {
+			return ref.toJSON()
+		}
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = replacePipelineIDs(item, byID)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = replacePipelineIDs(item, byID)
+		}
+	}
+	return doc
+}
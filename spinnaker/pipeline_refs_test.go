@@ -0,0 +1,57 @@
+package spinnaker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollectPipelineRefs(t *testing.T) {
+	pipe := decodeTestPipeline(t, `{
+		"stages": [{
+			"name": "Run Child",
+			"refId": "1",
+			"type": "pipeline",
+			"application": "child-app",
+			"pipeline": {"$pipelineRef": {"application": "child-app", "name": "Deploy"}}
+		}],
+		"triggers": [{
+			"type": "pipeline",
+			"application": "upstream",
+			"pipeline": {"$pipelineRef": {"application": "upstream", "name": "Build"}}
+		}]
+	}`)
+
+	refs, err := collectPipelineRefs(pipe)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(refs) != 2 {
+		t.Fatalf("expected 2 references, got %v", refs)
+	}
+
+	byID := map[string]pipelineRef{
+		"1111-aaaa": {application: "child-app", name: "Deploy"},
+	}
+	server := decodeTestPipeline(t, `{"stages": [{"refId": "1", "type": "pipeline", "pipeline": "1111-aaaa"}]}`)
+	restored := replacePipelineIDs(server, byID).(map[string]interface{})
+
+	expected := decodeTestPipeline(t, `{"stages": [{"refId": "1", "type": "pipeline",
+		"pipeline": {"$pipelineRef": {"application": "child-app", "name": "Deploy"}}}]}`)
+	if !reflect.DeepEqual(restored, expected) {
+		t.Fatalf("expected %v, got %v", expected, restored)
+	}
+}
+
+func TestCollectPipelineRefsInvalid(t *testing.T) {
+	invalid := []string{
+		`{"pipeline": {"$pipelineRef": {"application": "app"}}}`,
+		`{"pipeline": {"$pipelineRef": "app/name"}}`,
+		`{"pipeline": {"$pipelineRef": {"application": "app", "name": "x"}, "extra": true}}`,
+	}
+
+	for _, v := range invalid {
+		if _, err := collectPipelineRefs(decodeTestPipeline(t, v)); err == nil {
+			t.Fatalf("expected %s to be an invalid pipeline reference", v)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package spinnaker
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// changeGetter is implemented by both *schema.ResourceData and
+// *schema.ResourceDiff, so the summary can be computed at plan time and
+// again when the planned change has been applied.
+type changeGetter interface {
+	GetChange(key string) (interface{}, interface{})
+}
+
+// summarizePipelineAttributes summarizes the change planned for a
+// spinnaker_pipeline. Both sides are built the way the pipeline is saved,
+// with trigger blocks, the locked block and the disabled flag applied, so
+// changes made through any of them are summarized.
+func summarizePipelineAttributes(d changeGetter) ([]string, error) {
+	oldPipeline, newPipeline := d.GetChange("pipeline")
+	oldTriggers, newTriggers := d.GetChange("trigger")
+	oldLocked, newLocked := d.GetChange("locked")
+	oldDisabled, newDisabled := d.GetChange("disabled")
+
+	previous, err := expandPipelineSummaryDocument(oldPipeline.(string), oldTriggers.([]interface{}),
+		oldLocked.([]interface{}), oldDisabled.(bool))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode previous pipeline: %s", err)
+	}
+	current, err := expandPipelineSummaryDocument(newPipeline.(string), newTriggers.([]interface{}),
+		newLocked.([]interface{}), newDisabled.(bool))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode pipeline: %s", err)
+	}
+
+	summary := summarizePipelineChanges(previous, current)
+	if summary == nil {
+		summary = []string{}
+	}
+	return summary, nil
+}
+
+// expandPipelineSummaryDocument builds the normalized pipeline that
+// summarizePipelineChanges compares. Upstream pipelines of pipeline
+// triggers are left unresolved on both sides. A pipeline that does not
+// exist yet is nil.
+func expandPipelineSummaryDocument(pipeline string, triggers, locked []interface{}, disabled bool) (map[string]interface{}, error) {
+	if pipeline == "" {
+		return nil, nil
+	}
+
+	doc, err := decodePipeline(pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(triggers) > 0 {
+		expanded := make([]interface{}, 0, len(triggers))
+		for i, b := range triggers {
+			block, _ := b.(map[string]interface{})
+			trigger, err := expandTrigger(block)
+			if err != nil {
+				return nil, fmt.Errorf("trigger.%d: %s", i, err)
+			}
+			expanded = append(expanded, trigger)
+		}
+		doc["triggers"] = expanded
+	}
+
+	// normalizePipeline drops locked and disabled, which come from their
+	// own attributes, so they are put back afterwards.
+	normalizePipeline(doc)
+	if l := expandLockedBlocks(locked); l != nil {
+		doc["locked"] = l
+	}
+	if disabled {
+		doc["disabled"] = true
+	}
+
+	return doc, nil
+}
+
+// summarizePipelineChanges describes, one line per change, how the stages,
+// triggers and top level settings of a normalized pipeline differ between
+// old and new. It is shown in the plan next to the full JSON diff so
+// reviewers can see what changed at a glance.
+func summarizePipelineChanges(old, new map[string]interface{}) []string {
+	var summary []string
+
+	summary = append(summary, summarizeStageChanges(mapList(old["stages"]), mapList(new["stages"]))...)
+	summary = append(summary, summarizeTriggerChanges(mapList(old["triggers"]), mapList(new["triggers"]))...)
+
+	for _, key := range changedKeys(old, new) {
+		if key == "stages" || key == "triggers" {
+			continue
+		}
+		summary = append(summary, fmt.Sprintf("pipeline %s changed", key))
+	}
+
+	return summary
+}
+
+func summarizeStageChanges(old, new []map[string]interface{}) []string {
+	var summary []string
+
+	oldByRefID := make(map[string]map[string]interface{})
+	for _, stage := range old {
+		oldByRefID[refIDString(stage["refId"])] = stage
+	}
+	newByRefID := make(map[string]map[string]interface{})
+	for _, stage := range new {
+		newByRefID[refIDString(stage["refId"])] = stage
+	}
+
+	for _, stage := range old {
+		if _, ok := newByRefID[refIDString(stage["refId"])]; !ok {
+			summary = append(summary, fmt.Sprintf("stage %s removed", stageLabel(stage)))
+		}
+	}
+
+	for _, stage := range new {
+		previous, ok := oldByRefID[refIDString(stage["refId"])]
+		if !ok {
+			summary = append(summary, fmt.Sprintf("stage %s added", stageLabel(stage)))
+			continue
+		}
+
+		var changed []string
+		for _, key := range changedKeys(previous, stage) {
+			if key == "name" {
+				summary = append(summary, fmt.Sprintf("stage %s renamed to %s", stageLabel(previous), stageLabel(stage)))
+				continue
+			}
+			changed = append(changed, key)
+		}
+		if len(changed) > 0 {
+			summary = append(summary, fmt.Sprintf("stage %s %s changed", stageLabel(stage), strings.Join(changed, ", ")))
+		}
+	}
+
+	return summary
+}
+
+// summarizeTriggerChanges compares triggers of the same type pairwise.
+// Triggers have no stable identifier, so within a type they are matched by
+// position after normalization has put them in a canonical order.
+func summarizeTriggerChanges(old, new []map[string]interface{}) []string {
+	var summary []string
+
+	oldByType := groupByType(old)
+	newByType := groupByType(new)
+
+	types := make(map[string]bool)
+	for t := range oldByType {
+		types[t] = true
+	}
+	for t := range newByType {
+		types[t] = true
+	}
+	sortedTypes := make([]string, 0, len(types))
+	for t := range types {
+		sortedTypes = append(sortedTypes, t)
+	}
+	sort.Strings(sortedTypes)
+
+	for _, t := range sortedTypes {
+		o, n := oldByType[t], newByType[t]
+		for i := 0; i < len(o) || i < len(n); i++ {
+			switch {
+			case i >= len(o):
+				summary = append(summary, fmt.Sprintf("%s trigger added", t))
+			case i >= len(n):
+				summary = append(summary, fmt.Sprintf("%s trigger removed", t))
+			default:
+				if changed := changedKeys(o[i], n[i]); len(changed) > 0 {
+					summary = append(summary, fmt.Sprintf("%s trigger %s changed", t, strings.Join(changed, ", ")))
+				}
+			}
+		}
+	}
+
+	return summary
+}
+
+func groupByType(items []map[string]interface{}) map[string][]map[string]interface{} {
+	grouped := make(map[string][]map[string]interface{})
+	for _, item := range items {
+		t, _ := item["type"].(string)
+		grouped[t] = append(grouped[t], item)
+	}
+	return grouped
+}
+
+// changedKeys returns the sorted keys whose values differ between a and b,
+// including keys present in only one of them.
+func changedKeys(a, b map[string]interface{}) []string {
+	var keys []string
+	for k, v := range a {
+		if !reflect.DeepEqual(v, b[k]) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func mapList(v interface{}) []map[string]interface{} {
+	items, _ := v.([]interface{})
+	var maps []map[string]interface{}
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			maps = append(maps, m)
+		}
+	}
+	return maps
+}
+
+func stageLabel(stage map[string]interface{}) string {
+	if name, ok := stage["name"].(string); ok && name != "" {
+		return fmt.Sprintf("%q", name)
+	}
+	return fmt.Sprintf("refId %q", refIDString(stage["refId"]))
+}
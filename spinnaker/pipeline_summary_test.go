@@ -0,0 +1,94 @@
+package spinnaker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSummarizePipelineChanges(t *testing.T) {
+	old := decodeTestPipeline(t, `{
+		"description": "old",
+		"stages": [
+			{"name": "Bake", "refId": "1", "type": "bake"},
+			{"name": "Deploy Prod", "refId": "2", "type": "deploy", "stageTimeoutMs": 600000},
+			{"name": "Smoke Test", "refId": "3", "type": "jenkins"}
+		],
+		"triggers": [{"type": "cron", "cronExpression": "0 0 12 * * ?"}]
+	}`)
+	new := decodeTestPipeline(t, `{
+		"description": "new",
+		"stages": [
+			{"name": "Bake Image", "refId": "1", "type": "bake"},
+			{"name": "Deploy Prod", "refId": "2", "type": "deploy", "stageTimeoutMs": 900000},
+			{"name": "Notify", "refId": "4", "type": "wait"}
+		],
+		"triggers": [
+			{"type": "cron", "cronExpression": "0 0 6 * * ?"},
+			{"type": "git", "branch": "main"}
+		]
+	}`)
+
+	expected := []string{
+		`stage "Smoke Test" removed`,
+		`stage "Bake" renamed to "Bake Image"`,
+		`stage "Deploy Prod" stageTimeoutMs changed`,
+		`stage "Notify" added`,
+		`cron trigger cronExpression changed`,
+		`git trigger added`,
+		`pipeline description changed`,
+	}
+
+	summary := summarizePipelineChanges(old, new)
+	if strings.Join(summary, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected summary:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(summary, "\n"))
+	}
+}
+
+type testChanges map[string][2]interface{}
+
+func (c testChanges) GetChange(key string) (interface{}, interface{}) {
+	return c[key][0], c[key][1]
+}
+
+func TestSummarizePipelineAttributes(t *testing.T) {
+	pipeline := `{"stages": [{"name": "Bake", "refId": "1", "type": "bake"}]}`
+	cron := map[string]interface{}{
+		"enabled":               true,
+		"run_as_user":           "",
+		"expected_artifact_ids": []interface{}{},
+		"cron":                  []interface{}{map[string]interface{}{"cron_expression": "0 0 12 * * ?"}},
+	}
+	locked := map[string]interface{}{"ui": true, "allow_unlock_ui": false, "description": ""}
+
+	summary, err := summarizePipelineAttributes(testChanges{
+		"pipeline": {pipeline, pipeline},
+		"trigger":  {[]interface{}{}, []interface{}{cron}},
+		"locked":   {[]interface{}{}, []interface{}{locked}},
+		"disabled": {false, true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"cron trigger added",
+		"pipeline disabled changed",
+		"pipeline locked changed",
+	}
+	if strings.Join(summary, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected summary:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(summary, "\n"))
+	}
+
+	summary, err = summarizePipelineAttributes(testChanges{
+		"pipeline": {"", pipeline},
+		"trigger":  {[]interface{}{}, []interface{}{}},
+		"locked":   {[]interface{}{}, []interface{}{}},
+		"disabled": {false, false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(summary, "\n") != `stage "Bake" added` {
+		t.Fatalf("expected the new stage to be summarized, got %q", summary)
+	}
+}
@@ -0,0 +1,430 @@
+package spinnaker
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/structure"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/rmullinnix461332/terraform-provider-spinnaker/gateclient"
+)
+
+// templateConfigGetter is implemented by both *schema.ResourceData and
+// *schema.ResourceDiff, so a config can be built at plan and apply time.
+type templateConfigGetter interface {
+	Get(key string) interface{}
+}
+
+func jsonAttributeSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:             schema.TypeString,
+		Optional:         true,
+		ConflictsWith:    []string{"pipeline_config"},
+		ValidateFunc:     validation.StringIsJSON,
+		DiffSuppressFunc: structure.SuppressJsonDiff,
+	}
+}
+
+func stringListSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:          schema.TypeList,
+		Optional:      true,
+		ConflictsWith: []string{"pipeline_config"},
+		Elem:          &schema.Schema{Type: schema.TypeString},
+	}
+}
+
+// templateConfigSchema returns the structured attributes of
+// spinnaker_pipeline_template_config.
+func templateConfigSchema() map[string]*schema.Schema {
+	trigger := triggerSchema()
+	trigger.ConflictsWith = []string{"pipeline_config"}
+
+	return map[string]*schema.Schema{
+		"template_reference": {
+			Type:          schema.TypeList,
+			Optional:      true,
+			MaxItems:      1,
+			ConflictsWith: []string{"pipeline_config"},
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"id": {
+						Type:         schema.TypeString,
+						Required:     true,
+						ValidateFunc: validateTemplateName,
+					},
+					"tag": {
+						Type:         schema.TypeString,
+						Optional:     true,
+						ValidateFunc: validateTemplateTag,
+					},
+				},
+			},
+		},
+		"variables": {
+			Type:             schema.TypeMap,
+			Optional:         true,
+			ConflictsWith:    []string{"pipeline_config"},
+			Elem:             &schema.Schema{Type: schema.TypeString},
+			DiffSuppressFunc: suppressEquivalentTemplateVariables,
+		},
+		"exclude":       stringListSchema(),
+		"inherit":       stringListSchema(),
+		"notifications": jsonAttributeSchema(),
+		"trigger":       trigger,
+		"stage": {
+			Type:          schema.TypeList,
+			Optional:      true,
+			ConflictsWith: []string{"pipeline_config"},
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"id": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"type": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"name": {
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"depends_on": {
+						Type:     schema.TypeList,
+						Optional: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+					"config": {
+						Type:             schema.TypeString,
+						Optional:         true,
+						ValidateFunc:     validation.All(validation.StringIsJSON, validatePipelineExpressions),
+						DiffSuppressFunc: structure.SuppressJsonDiff,
+					},
+					"inject": {
+						Type:             schema.TypeString,
+						Optional:         true,
+						ValidateFunc:     validation.All(validation.StringIsJSON, validatePipelineExpressions),
+						DiffSuppressFunc: structure.SuppressJsonDiff,
+					},
+				},
+			},
+		},
+		"parameter": {
+			Type:          schema.TypeList,
+			Optional:      true,
+			ConflictsWith: []string{"pipeline_config"},
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"name": {
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"label": {
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"description": {
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"default": {
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"required": {
+						Type:     schema.TypeBool,
+						Optional: true,
+						Default:  false,
+					},
+					"options": {
+						Type:     schema.TypeList,
+						Optional: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+				},
+			},
+		},
+	}
+}
+
+// templateConfigStructured reports whether a config is built from the
+// structured attributes rather than pipeline_config.
+func templateConfigStructured(d templateConfigGetter) bool {
+	references, _ := d.Get("template_reference").([]interface{})
+	return len(references) > 0
+}
+
+// expandTemplateConfig builds the templated pipeline config from the
+// structured attributes. Pipeline triggers are left with their upstream
+// pipeline unresolved. Variables are decoded as the types the template
+// declares, when client is set and the template exists.
+func expandTemplateConfig(d templateConfigGetter, client *gateclient.GatewayClient) (map[string]interface{}, error) {
+	references := d.Get("template_reference").([]interface{})
+	reference, _ := references[0].(map[string]interface{})
+	templateID, _ := reference["id"].(string)
+	tag, _ := reference["tag"].(string)
+
+	config := map[string]interface{}{
+		"schema":      "v2",
+		"application": d.Get("application").(string),
+		"name":        d.Get("template_name").(string),
+		"template":    pipelineTemplateReference(pipelineTemplateURL(templateID, tag)),
+	}
+
+	if values := d.Get("variables").(map[string]interface{}); len(values) > 0 {
+		types, err := templateVariableTypes(client, templateID, tag)
+		if err != nil {
+			return nil, err
+		}
+
+		variables := make(map[string]interface{}, len(values))
+		for name, value := range values {
+			variables[name] = decodeTemplateVariable(value.(string), types[name])
+		}
+		config["variables"] = variables
+	}
+
+	if v := d.Get("notifications").(string); v != "" {
+		var notifications []interface{}
+		if err := json.Unmarshal([]byte(v), &notifications); err != nil {
+			return nil, fmt.Errorf("notifications must be a JSON list: %s", err)
+		}
+		config["notifications"] = notifications
+	}
+
+	for _, key := range []string{"exclude", "inherit"} {
+		if v := d.Get(key).([]interface{}); len(v) > 0 {
+			config[key] = v
+		}
+	}
+
+	if blocks := d.Get("stage").([]interface{}); len(blocks) > 0 {
+		stages := make([]interface{}, 0, len(blocks))
+		for i, b := range blocks {
+			stage, err := expandTemplateConfigStage(b.(map[string]interface{}))
+			if err != nil {
+				return nil, fmt.Errorf("stage.%d: %s", i, err)
+			}
+			stages = append(stages, stage)
+		}
+		config["stages"] = stages
+	}
+
+	if blocks := d.Get("trigger").([]interface{}); len(blocks) > 0 {
+		triggers := make([]interface{}, 0, len(blocks))
+		for i, b := range blocks {
+			trigger, err := expandTrigger(b.(map[string]interface{}))
+			if err != nil {
+				return nil, fmt.Errorf("trigger.%d: %s", i, err)
+			}
+			triggers = append(triggers, trigger)
+		}
+		config["triggers"] = triggers
+	}
+
+	if blocks := d.Get("parameter").([]interface{}); len(blocks) > 0 {
+		parameters := make([]interface{}, 0, len(blocks))
+		for _, b := range blocks {
+			parameters = append(parameters, expandTemplateConfigParameter(b.(map[string]interface{})))
+		}
+		config["parameterConfig"] = parameters
+	}
+
+	return config, nil
+}
+
+func expandTemplateConfigStage(block map[string]interface{}) (map[string]interface{}, error) {
+	stage := map[string]interface{}{
+		"id":   block["id"],
+		"type": block["type"],
+	}
+	if name, _ := block["name"].(string); name != "" {
+		stage["name"] = name
+	}
+	if dependsOn, _ := block["depends_on"].([]interface{}); len(dependsOn) > 0 {
+		stage["dependsOn"] = dependsOn
+	}
+
+	for _, key := range []string{"config", "inject"} {
+		v, _ := block[key].(string)
+		if v == "" {
+			continue
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &decoded); err != nil {
+			return nil, fmt.Errorf("%s must be a JSON object: %s", key, err)
+		}
+		stage[key] = decoded
+	}
+
+	return stage, nil
+}
+
+func expandTemplateConfigParameter(block map[string]interface{}) map[string]interface{} {
+	parameter := map[string]interface{}{
+		"name":     block["name"],
+		"required": block["required"],
+	}
+	for _, key := range []string{"label", "description", "default"} {
+		if v, _ := block[key].(string); v != "" {
+			parameter[key] = v
+		}
+	}
+
+	options, _ := block["options"].([]interface{})
+	parameter["hasOptions"] = len(options) > 0
+	if len(options) > 0 {
+		values := make([]interface{}, 0, len(options))
+		for _, o := range options {
+			values = append(values, map[string]interface{}{"value": o})
+		}
+		parameter["options"] = values
+	}
+
+	return parameter
+}
+
+// flattenTemplateConfig sets the structured attributes from a config read
+// from Spinnaker.
+func flattenTemplateConfig(data *schema.ResourceData, config map[string]interface{}, client *gateclient.GatewayClient) error {
+	template, _ := config["template"].(map[string]interface{})
+	templateID, tag, err := parsePipelineTemplateURL(stringValue(template["reference"]))
+	if err != nil {
+		return err
+	}
+
+	values, _ := config["variables"].(map[string]interface{})
+	variables := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		if variables[name], err = encodeTemplateVariable(value); err != nil {
+			return err
+		}
+	}
+
+	value, set := config["notifications"]
+	notifications, err := encodeJSONAttribute(value, set && value != nil)
+	if err != nil {
+		return err
+	}
+
+	raw, _ := config["stages"].([]interface{})
+	stages := make([]interface{}, 0, len(raw))
+	for _, s := range raw {
+		stage, _ := s.(map[string]interface{})
+		block := map[string]interface{}{
+			"id":         stringValue(stage["id"]),
+			"type":       stringValue(stage["type"]),
+			"name":       stringValue(stage["name"]),
+			"depends_on": stage["dependsOn"],
+		}
+		for _, key := range []string{"config", "inject"} {
+			value, set := stage[key]
+			if block[key], err = encodeJSONAttribute(value, set); err != nil {
+				return err
+			}
+		}
+		stages = append(stages, block)
+	}
+
+	triggers, err := flattenPipelineTriggers(config["triggers"], client)
+	if err != nil {
+		return err
+	}
+
+	attributes := map[string]interface{}{
+		"template_reference": []interface{}{map[string]interface{}{"id": templateID, "tag": tag}},
+		"variables":          variables,
+		"exclude":            config["exclude"],
+		"inherit":            config["inherit"],
+		"notifications":      notifications,
+		"stage":              stages,
+		"trigger":            triggers,
+		"parameter":          flattenPipelineParameters(config["parameterConfig"]),
+		"pipeline_config":    "",
+	}
+	for key, value := range attributes {
+		if err := data.Set(key, value); err != nil {
+			return fmt.Errorf("Could not set %s for pipeline %s: %s", key, stringValue(config["name"]), err)
+		}
+	}
+
+	return nil
+}
+
+// templateVariableTypes maps the variables a template declares to their
+// types. Without a client, or when the template does not exist yet, e.g.
+// because it is created in the same apply, the map is empty.
+func templateVariableTypes(client *gateclient.GatewayClient, templateID, tag string) (map[string]string, error) {
+	types := make(map[string]string)
+	if client == nil {
+		return types, nil
+	}
+
+	t := make(map[string]interface{})
+	if err := client.GetPipelineTemplate(templateID, tag, &t); err != nil {
+		if err.Error() == gateclient.ErrCodeNoSuchEntityException {
+			return types, nil
+		}
+		return nil, err
+	}
+
+	declared, _ := t["variables"].([]interface{})
+	for _, d := range declared {
+		if variable, ok := d.(map[string]interface{}); ok {
+			types[stringValue(variable["name"])] = stringValue(variable["type"])
+		}
+	}
+	return types, nil
+}
+
+// decodeTemplateVariable reads a value of the variables map, which
+// Terraform only allows to hold strings. Values of string variables are
+// kept as they are. Anything else that is valid JSON, such as 3, true or a
+// jsonencode()d list, is decoded, which is also how values are read when
+// the type is not known.
+func decodeTemplateVariable(value, variableType string) interface{} {
+	if variableType == "string" {
+		return value
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+// encodeTemplateVariable renders a variable for the variables map. Strings
+// are kept as they are and anything else is encoded as JSON.
+func encodeTemplateVariable(value interface{}) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// suppressEquivalentTemplateVariables ignores variables whose values
+// decode to the same thing, e.g. JSON that only differs in whitespace.
+func suppressEquivalentTemplateVariables(k, old, new string, d *schema.ResourceData) bool {
+	return reflect.DeepEqual(decodeTemplateVariable(old, ""), decodeTemplateVariable(new, ""))
+}
+
+// encodeJSONAttribute renders a value for a JSON string attribute, or an
+// empty string when the value is not set.
+func encodeJSONAttribute(value interface{}, set bool) (string, error) {
+	if !set {
+		return "", nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
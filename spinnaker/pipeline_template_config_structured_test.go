@@ -0,0 +1,158 @@
+package spinnaker
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type testTemplateConfig map[string]interface{}
+
+func (c testTemplateConfig) Get(key string) interface{} {
+	if v, ok := c[key]; ok {
+		return v
+	}
+	switch key {
+	case "variables":
+		return map[string]interface{}{}
+	case "notifications":
+		return ""
+	}
+	return []interface{}{}
+}
+
+func TestExpandTemplateConfig(t *testing.T) {
+	config, err := expandTemplateConfig(testTemplateConfig{
+		"application":        "web",
+		"template_name":      "Deploy",
+		"template_reference": []interface{}{map[string]interface{}{"id": "deploy-template", "tag": "v2"}},
+		"variables": map[string]interface{}{
+			"replicas": "3",
+			"regions":  `["us-east-1"]`,
+			"owner":    "team@example.com",
+		},
+		"exclude": []interface{}{"smoke-test"},
+		"stage": []interface{}{
+			map[string]interface{}{
+				"id":         "notify",
+				"type":       "wait",
+				"name":       "",
+				"depends_on": []interface{}{"deploy"},
+				"config":     `{"waitTime": 30}`,
+				"inject":     "",
+			},
+		},
+		"parameter": []interface{}{
+			map[string]interface{}{
+				"name":        "env",
+				"label":       "",
+				"description": "",
+				"default":     "dev",
+				"required":    true,
+				"options":     []interface{}{"dev", "prod"},
+			},
+		},
+		"trigger": []interface{}{
+			map[string]interface{}{
+				"enabled":               true,
+				"run_as_user":           "",
+				"expected_artifact_ids": []interface{}{},
+				"cron":                  []interface{}{map[string]interface{}{"cron_expression": "0 0 12 * * ?"}},
+			},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := decodeTestPipeline(t, `{
+		"schema": "v2",
+		"application": "web",
+		"name": "Deploy",
+		"template": {
+			"artifactAccount": "front50ArtifactCredentials",
+			"reference": "spinnaker://deploy-template:v2",
+			"type": "front50/pipelineTemplate"
+		},
+		"variables": {"owner": "team@example.com", "replicas": 3, "regions": ["us-east-1"]},
+		"exclude": ["smoke-test"],
+		"stages": [{"id": "notify", "type": "wait", "dependsOn": ["deploy"], "config": {"waitTime": 30}}],
+		"parameterConfig": [{"name": "env", "default": "dev", "required": true, "hasOptions": true,
+			"options": [{"value": "dev"}, {"value": "prod"}]}],
+		"triggers": [{"type": "cron", "enabled": true, "cronExpression": "0 0 12 * * ?"}]
+	}`)
+
+	got, _ := json.Marshal(config)
+	want, _ := json.Marshal(expected)
+	if string(got) != string(want) {
+		t.Fatalf("expected config:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestDecodeTemplateVariable(t *testing.T) {
+	tests := []struct {
+		value        string
+		variableType string
+		want         interface{}
+	}{
+		{"8080", "string", "8080"},
+		{"true", "string", "true"},
+		{`["us-east-1"]`, "string", `["us-east-1"]`},
+		{"8080", "int", float64(8080)},
+		{"true", "boolean", true},
+		{`["us-east-1"]`, "list", []interface{}{"us-east-1"}},
+		{`{"stack":"dev"}`, "object", map[string]interface{}{"stack": "dev"}},
+		{"${ trigger.tag }", "int", "${ trigger.tag }"},
+		{"web", "", "web"},
+		{"3", "", float64(3)},
+	}
+
+	for _, test := range tests {
+		decoded := decodeTemplateVariable(test.value, test.variableType)
+		if !reflect.DeepEqual(decoded, test.want) {
+			t.Errorf("decodeTemplateVariable(%q, %q) = %#v, want %#v", test.value, test.variableType, decoded, test.want)
+		}
+
+		encoded, err := encodeTemplateVariable(decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encoded != test.value {
+			t.Errorf("encodeTemplateVariable(%#v) = %q, want %q", decoded, encoded, test.value)
+		}
+	}
+}
+
+func TestFlattenTemplateConfig(t *testing.T) {
+	config := decodeTestPipeline(t, `{
+		"schema": "v2",
+		"application": "web",
+		"name": "Deploy",
+		"template": {"reference": "spinnaker://deploy-template:v2"},
+		"variables": {"replicas": 3, "owner": "team@example.com"},
+		"exclude": ["smoke-test"],
+		"stages": [{"id": "notify", "type": "wait", "dependsOn": ["deploy"], "config": {"waitTime": 30}}],
+		"triggers": [{"type": "cron", "enabled": true, "cronExpression": "0 0 12 * * ?"}]
+	}`)
+
+	data := schema.TestResourceDataRaw(t, resourcePipelineTemplateConfig().Schema, map[string]interface{}{})
+	if err := flattenTemplateConfig(data, config, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{"replicas": "3", "owner": "team@example.com"}
+	if variables := data.Get("variables"); !reflect.DeepEqual(variables, expected) {
+		t.Errorf("expected variables %v, got %v", expected, variables)
+	}
+	if exclude := data.Get("exclude"); !reflect.DeepEqual(exclude, []interface{}{"smoke-test"}) {
+		t.Errorf("expected exclude [smoke-test], got %v", exclude)
+	}
+	if config := data.Get("stage.0.config"); config != `{"waitTime":30}` {
+		t.Errorf("expected stage config {\"waitTime\":30}, got %v", config)
+	}
+	if cron := data.Get("trigger.0.cron.0.cron_expression"); cron != "0 0 12 * * ?" {
+		t.Errorf("expected cron trigger, got %v", cron)
+	}
+}
@@ -0,0 +1,397 @@
+package spinnaker
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/rmullinnix461332/terraform-provider-spinnaker/gateclient"
+)
+
+// triggerField maps an attribute of a trigger block to its key in the
+// pipeline's trigger JSON.
+type triggerField struct {
+	attribute string
+	key       string
+	schema    *schema.Schema
+}
+
+func requiredString() *schema.Schema {
+	return &schema.Schema{Type: schema.TypeString, Required: true}
+}
+
+func optionalString() *schema.Schema {
+	return &schema.Schema{Type: schema.TypeString, Optional: true}
+}
+
+func optionalStringMap() *schema.Schema {
+	return &schema.Schema{Type: schema.TypeMap, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}}
+}
+
+// triggerTypes lists the fields of each supported trigger type, keyed by
+// the Spinnaker trigger type, which is also the name of its block.
+// pipeline_name has no key; it is resolved to the upstream pipeline's ID.
+var triggerTypes = map[string][]triggerField{
+	"cron": {
+		{"cron_expression", "cronExpression", &schema.Schema{
+			Type:         schema.TypeString,
+			Required:     true,
+			ValidateFunc: validateQuartzCronExpression,
+		}},
+	},
+	"git": {
+		{"source", "source", &schema.Schema{
+			Type:         schema.TypeString,
+			Required:     true,
+			ValidateFunc: validation.StringInSlice([]string{"bitbucket", "github", "gitlab", "stash"}, false),
+		}},
+		{"project", "project", requiredString()},
+		{"slug", "slug", requiredString()},
+		{"branch", "branch", optionalString()},
+		{"secret", "secret", &schema.Schema{Type: schema.TypeString, Optional: true, Sensitive: true}},
+	},
+	"docker": {
+		{"account", "account", requiredString()},
+		{"registry", "registry", optionalString()},
+		{"organization", "organization", optionalString()},
+		{"repository", "repository", requiredString()},
+		{"tag", "tag", optionalString()},
+	},
+	"jenkins": {
+		{"master", "master", requiredString()},
+		{"job", "job", requiredString()},
+		{"property_file", "propertyFile", optionalString()},
+	},
+	"pipeline": {
+		{"application", "application", &schema.Schema{
+			Type:         schema.TypeString,
+			Required:     true,
+			ValidateFunc: validateApplicationName,
+		}},
+		{"pipeline_name", "", requiredString()},
+		{"status", "status", &schema.Schema{
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem: &schema.Schema{
+				Type:         schema.TypeString,
+				ValidateFunc: validation.StringInSlice([]string{"successful", "failed", "canceled"}, false),
+			},
+		}},
+	},
+	"webhook": {
+		{"source", "source", requiredString()},
+		{"payload_constraints", "payloadConstraints", optionalStringMap()},
+	},
+	"pubsub": {
+		{"pubsub_system", "pubsubSystem", &schema.Schema{
+			Type:         schema.TypeString,
+			Required:     true,
+			ValidateFunc: validation.StringInSlice([]string{"amazon", "google"}, false),
+		}},
+		{"subscription_name", "subscriptionName", requiredString()},
+		{"payload_constraints", "payloadConstraints", optionalStringMap()},
+		{"attribute_constraints", "attributeConstraints", optionalStringMap()},
+	},
+	"artifactory": {
+		{"artifactory_search_name", "artifactorySearchName", requiredString()},
+	},
+	"helm": {
+		{"account", "account", requiredString()},
+		{"chart", "chart", requiredString()},
+		{"version", "version", optionalString()},
+	},
+	"plugin": {
+		{"plugin_id", "pluginId", requiredString()},
+		{"version", "version", optionalString()},
+	},
+}
+
+func sortedTriggerTypes() []string {
+	types := make([]string, 0, len(triggerTypes))
+	for t := range triggerTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// triggerSchema is the `trigger` block of spinnaker_pipeline. Each trigger
+// holds exactly one nested block named after its type.
+func triggerSchema() *schema.Schema {
+	s := map[string]*schema.Schema{
+		"enabled": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  true,
+		},
+		"run_as_user": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"expected_artifact_ids": {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+	}
+
+	for triggerType, fields := range triggerTypes {
+		typeSchema := make(map[string]*schema.Schema, len(fields))
+		for _, f := range fields {
+			typeSchema[f.attribute] = f.schema
+		}
+		s[triggerType] = &schema.Schema{
+			Type:     schema.TypeList,
+			Optional: true,
+			MaxItems: 1,
+			Elem:     &schema.Resource{Schema: typeSchema},
+		}
+	}
+
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		Elem:     &schema.Resource{Schema: s},
+	}
+}
+
+// triggerBlockType returns the type of a trigger block, and an error
+// unless exactly one type block is set.
+func triggerBlockType(block map[string]interface{}) (string, map[string]interface{}, error) {
+	var found []string
+	var settings map[string]interface{}
+	for _, t := range sortedTriggerTypes() {
+		if typed, ok := block[t].([]interface{}); ok && len(typed) > 0 {
+			found = append(found, t)
+			settings, _ = typed[0].(map[string]interface{})
+		}
+	}
+
+	if len(found) != 1 {
+		return "", nil, fmt.Errorf("each trigger must contain exactly one of %v, found %v", sortedTriggerTypes(), found)
+	}
+	if settings == nil {
+		settings = make(map[string]interface{})
+	}
+	return found[0], settings, nil
+}
+
+// expandTrigger converts a trigger block to its JSON form. Pipeline
+// triggers are returned with their pipeline_name unresolved.
+func expandTrigger(block map[string]interface{}) (map[string]interface{}, error) {
+	triggerType, settings, err := triggerBlockType(block)
+	if err != nil {
+		return nil, err
+	}
+
+	trigger := map[string]interface{}{
+		"type":    triggerType,
+		"enabled": block["enabled"],
+	}
+	if runAsUser, _ := block["run_as_user"].(string); runAsUser != "" {
+		trigger["runAsUser"] = runAsUser
+	}
+	if ids, _ := block["expected_artifact_ids"].([]interface{}); len(ids) > 0 {
+		trigger["expectedArtifactIds"] = ids
+	}
+
+	for _, f := range triggerTypes[triggerType] {
+		if f.key == "" {
+			continue
+		}
+		switch v := settings[f.attribute].(type) {
+		case string:
+			if v != "" {
+				trigger[f.key] = v
+			}
+		case []interface{}:
+			if len(v) > 0 {
+				trigger[f.key] = v
+			}
+		case map[string]interface{}:
+			if len(v) > 0 {
+				trigger[f.key] = v
+			}
+		}
+	}
+
+	return trigger, nil
+}
+
+// expandPipelineTriggers builds the triggers for the pipeline payload,
+// resolving the upstream pipeline of each pipeline trigger to its ID.
+func expandPipelineTriggers(data *schema.ResourceData, client *gateclient.GatewayClient) ([]interface{}, error) {
+	blocks := data.Get("trigger").([]interface{})
+	triggers := make([]interface{}, 0, len(blocks))
+
+	for _, b := range blocks {
+		block := b.(map[string]interface{})
+		trigger, err := expandTrigger(block)
+		if err != nil {
+			return nil, err
+		}
+
+		if trigger["type"] == "pipeline" {
+			_, settings, _ := triggerBlockType(block)
+			application, _ := settings["application"].(string)
+			pipelineName, _ := settings["pipeline_name"].(string)
+
+			var p pipelineRead
+			if _, err := client.GetPipeline(application, pipelineName, &p); err != nil {
+				if err.Error() == gateclient.ErrCodeNoSuchEntityException {
+					return nil, fmt.Errorf("pipeline trigger references pipeline %q in application %q, which does not exist",
+						pipelineName, application)
+				}
+				return nil, err
+			}
+			trigger["pipeline"] = p.ID
+		}
+
+		triggers = append(triggers, trigger)
+	}
+
+	return triggers, nil
+}
+
+// flattenPipelineTriggers converts the triggers of a pipeline to trigger
+// blocks. Upstream pipeline IDs are mapped back to names, fetching the
+// pipelines of each upstream application once; triggers of types without
+// a block are skipped with a warning.
+func flattenPipelineTriggers(v interface{}, client *gateclient.GatewayClient) ([]interface{}, error) {
+	raw, _ := v.([]interface{})
+	blocks := make([]interface{}, 0, len(raw))
+	names := make(map[string]map[string]string)
+
+	for _, r := range raw {
+		trigger, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		triggerType, _ := trigger["type"].(string)
+		fields, ok := triggerTypes[triggerType]
+		if !ok {
+			log.Printf("[WARN] ignoring trigger of unsupported type %q", triggerType)
+			continue
+		}
+
+		enabled, ok := trigger["enabled"].(bool)
+		if !ok {
+			enabled = true
+		}
+		runAsUser, _ := trigger["runAsUser"].(string)
+		expectedArtifactIds, _ := trigger["expectedArtifactIds"].([]interface{})
+
+		settings := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			if f.key != "" {
+				if value, ok := trigger[f.key]; ok {
+					settings[f.attribute] = value
+				}
+			}
+		}
+
+		if triggerType == "pipeline" {
+			application, _ := trigger["application"].(string)
+			pipelineID, _ := trigger["pipeline"].(string)
+			if _, ok := names[application]; !ok {
+				var err error
+				if names[application], err = pipelineNamesByID(client, application); err != nil {
+					return nil, err
+				}
+			}
+			// If the pipeline no longer exists the ID is kept, so the
+			// difference shows up in the plan.
+			name, ok := names[application][pipelineID]
+			if !ok {
+				name = pipelineID
+			}
+			settings["pipeline_name"] = name
+		}
+
+		blocks = append(blocks, map[string]interface{}{
+			"enabled":               enabled,
+			"run_as_user":           runAsUser,
+			"expected_artifact_ids": expectedArtifactIds,
+			triggerType:             []interface{}{settings},
+		})
+	}
+
+	return blocks, nil
+}
+
+// pipelineNamesByID maps the IDs of the pipelines in an application to
+// their names. An application that does not exist has no pipelines.
+func pipelineNamesByID(client *gateclient.GatewayClient, application string) (map[string]string, error) {
+	pipelines, err := client.GetPipelines(application)
+	if err != nil {
+		if err.Error() == gateclient.ErrCodeNoSuchEntityException {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	names := make(map[string]string, len(pipelines))
+	for _, p := range pipelines {
+		id, _ := p["id"].(string)
+		name, _ := p["name"].(string)
+		names[id] = name
+	}
+
+	return names, nil
+}
+
+// pipelineTriggersManaged reports whether the triggers of a pipeline are
+// managed through trigger blocks rather than the pipeline JSON.
+func pipelineTriggersManaged(data *schema.ResourceData) bool {
+	blocks, _ := data.Get("trigger").([]interface{})
+	return len(blocks) > 0
+}
+
+// validateTriggerBlocks checks the trigger blocks of a planned pipeline and
+// returns them in JSON form, without upstream pipelines resolved. Upstream
+// pipelines that cannot be found are only logged as a warning, since they
+// may be created in the same apply.
+func validateTriggerBlocks(diff *schema.ResourceDiff, pipeline map[string]interface{}, client *gateclient.GatewayClient) ([]interface{}, error) {
+	blocks, _ := diff.Get("trigger").([]interface{})
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+
+	if triggers, _ := pipeline["triggers"].([]interface{}); len(triggers) > 0 {
+		return nil, fmt.Errorf("triggers must be declared either in pipeline or as trigger blocks, not both")
+	}
+
+	triggers := make([]interface{}, 0, len(blocks))
+	for i, b := range blocks {
+		block, _ := b.(map[string]interface{})
+		trigger, err := expandTrigger(block)
+		if err != nil {
+			return nil, fmt.Errorf("trigger.%d: %s", i, err)
+		}
+		triggers = append(triggers, trigger)
+
+		application, _ := trigger["application"].(string)
+		if trigger["type"] != "pipeline" || client == nil || application == "" {
+			continue
+		}
+		_, settings, _ := triggerBlockType(block)
+		pipelineName, _ := settings["pipeline_name"].(string)
+		if pipelineName == "" {
+			continue
+		}
+
+		var p pipelineRead
+		if _, err := client.GetPipeline(application, pipelineName, &p); err != nil {
+			if err.Error() != gateclient.ErrCodeNoSuchEntityException {
+				return nil, err
+			}
+			log.Printf("[WARN] trigger.%d: upstream pipeline %q in application %q does not exist yet",
+				i, pipelineName, application)
+		}
+	}
+
+	return triggers, nil
+}
@@ -0,0 +1,81 @@
+package spinnaker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandAndFlattenTriggers(t *testing.T) {
+	blocks := []interface{}{
+		map[string]interface{}{
+			"enabled":               true,
+			"run_as_user":           "",
+			"expected_artifact_ids": []interface{}{},
+			"cron": []interface{}{map[string]interface{}{
+				"cron_expression": "0 0 12 * * ?",
+			}},
+		},
+		map[string]interface{}{
+			"enabled":               false,
+			"run_as_user":           "deploy-bot",
+			"expected_artifact_ids": []interface{}{"artifact-1"},
+			"docker": []interface{}{map[string]interface{}{
+				"account":    "dockerhub",
+				"repository": "org/app",
+				"tag":        "^v.*",
+			}},
+		},
+	}
+
+	var triggers []interface{}
+	for _, b := range blocks {
+		trigger, err := expandTrigger(b.(map[string]interface{}))
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		triggers = append(triggers, trigger)
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{"type": "cron", "enabled": true, "cronExpression": "0 0 12 * * ?"},
+		map[string]interface{}{"type": "docker", "enabled": false, "runAsUser": "deploy-bot",
+			"expectedArtifactIds": []interface{}{"artifact-1"}, "account": "dockerhub", "repository": "org/app", "tag": "^v.*"},
+	}
+	if !reflect.DeepEqual(triggers, expected) {
+		t.Fatalf("expected triggers %v, got %v", expected, triggers)
+	}
+
+	flattened, err := flattenPipelineTriggers(triggers, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(flattened) != 2 {
+		t.Fatalf("expected 2 trigger blocks, got %v", flattened)
+	}
+	docker := flattened[1].(map[string]interface{})
+	if docker["run_as_user"] != "deploy-bot" || docker["enabled"] != false {
+		t.Fatalf("unexpected docker trigger block %v", docker)
+	}
+	settings := docker["docker"].([]interface{})[0].(map[string]interface{})
+	if settings["repository"] != "org/app" || settings["tag"] != "^v.*" {
+		t.Fatalf("unexpected docker trigger settings %v", settings)
+	}
+}
+
+func TestExpandTriggerRequiresOneType(t *testing.T) {
+	invalid := []map[string]interface{}{
+		{"enabled": true},
+		{
+			"enabled": true,
+			"cron":    []interface{}{map[string]interface{}{"cron_expression": "0 0 12 * * ?"}},
+			"git":     []interface{}{map[string]interface{}{"source": "github", "project": "org", "slug": "app"}},
+		},
+	}
+
+	for _, block := range invalid {
+		if _, err := expandTrigger(block); err == nil || !strings.Contains(err.Error(), "exactly one of") {
+			t.Fatalf("expected error for trigger block %v, got %v", block, err)
+		}
+	}
+}
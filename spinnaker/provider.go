@@ -42,15 +42,28 @@ func New() *schema.Provider {
 				Description: "Headers to be passed to the gate endpoint by the client on each request",
 				Default:     "",
 			},
+			"validate_expressions": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Check pipeline expressions in pipelines and templates at plan time",
+				Default:     false,
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
 			"spinnaker_application":              resourceApplication(),
 			"spinnaker_pipeline":                 resourcePipeline(),
+			"spinnaker_pipeline_execution":       resourcePipelineExecution(),
+			"spinnaker_pipeline_strategy":        resourcePipelineStrategy(),
 			"spinnaker_pipeline_template":        resourcePipelineTemplate(),
 			"spinnaker_pipeline_template_config": resourcePipelineTemplateConfig(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"spinnaker_pipeline": datasourcePipeline(),
+			"spinnaker_pipeline":                    datasourcePipeline(),
+			"spinnaker_pipeline_executions":         datasourcePipelineExecutions(),
+			"spinnaker_pipeline_template_migration": datasourcePipelineTemplateMigration(),
+			"spinnaker_pipeline_template_plan":      datasourcePipelineTemplatePlan(),
+			"spinnaker_pipeline_template_versions":  datasourcePipelineTemplateVersions(),
+			"spinnaker_pipelines":                   datasourcePipelines(),
 		},
 		ConfigureContextFunc: providerConfigure,
 	}
@@ -59,8 +72,9 @@ func New() *schema.Provider {
 }
 
 type gateConfig struct {
-	server string
-	client *gateclient.GatewayClient
+	server              string
+	client              *gateclient.GatewayClient
+	validateExpressions bool
 }
 
 func providerConfigure(ctx context.Context, data *schema.ResourceData) (interface{}, diag.Diagnostics) {
@@ -69,6 +83,7 @@ func providerConfigure(ctx context.Context, data *schema.ResourceData) (interfac
 	x509_key := data.Get("x509_key").(string)
 	ignoreCertErrors := data.Get("ignore_cert_errors").(bool)
 	defaultHeaders := data.Get("default_headers").(string)
+	validateExpressions := data.Get("validate_expressions").(bool)
 
 	client, err := gateclient.NewGateClient(server, defaultHeaders, x509_cert, x509_key, ignoreCertErrors)
 
@@ -76,7 +91,8 @@ func providerConfigure(ctx context.Context, data *schema.ResourceData) (interfac
 		fmt.Println("config error", err)
 	}
 	return gateConfig{
-		server: server,
-		client: client,
+		server:              server,
+		client:              client,
+		validateExpressions: validateExpressions,
 	}, diag.Diagnostics{}
 }
@@ -1,11 +1,23 @@
 package spinnaker
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ghodss/yaml"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/rmullinnix461332/terraform-provider-spinnaker/gateclient"
+)
+
+const (
+	pipelineFormatJSON = "json"
+	pipelineFormatYAML = "yaml"
 )
 
 func resourcePipeline() *schema.Resource {
@@ -26,35 +38,215 @@ func resourcePipeline() *schema.Resource {
 				Type:             schema.TypeString,
 				Required:         true,
 				DiffSuppressFunc: pipelineDiffSuppressFunc,
+				StateFunc:        pipelineStateFunc,
+				ValidateFunc:     validation.All(validatePipelineExpressions, validatePipelineParameterUsage),
 			},
 			"pipeline_id": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"pipeline_format": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"update_ts": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"last_modified_by": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"locked": lockedSchema(),
+			"disabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+			"ignore_disabled_drift": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"trigger": triggerSchema(),
+			"force_overwrite": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"pipeline_summary": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
 		},
-		Create: resourcePipelineCreate,
-		Read:   resourcePipelineRead,
-		Update: resourcePipelineUpdate,
-		Delete: resourcePipelineDelete,
-		Exists: resourcePipelineExists,
+		Create:        resourcePipelineCreate,
+		Read:          resourcePipelineRead,
+		Update:        resourcePipelineUpdate,
+		Delete:        resourcePipelineDelete,
+		Exists:        resourcePipelineExists,
+		CustomizeDiff: resourcePipelineCustomizeDiff,
 	}
 }
 
+func resourcePipelineCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	if !diff.NewValueKnown("pipeline") {
+		return nil
+	}
+
+	pipe, err := decodePipeline(diff.Get("pipeline").(string))
+	if err != nil {
+		return fmt.Errorf("could not decode pipeline: %s", err)
+	}
+
+	if err := diff.SetNew("pipeline_format", detectPipelineFormat(diff.Get("pipeline").(string))); err != nil {
+		return err
+	}
+
+	if err := validateStageGraph(pipe); err != nil {
+		return err
+	}
+
+	if err := resourcePipelineDisabledCustomizeDiff(diff); err != nil {
+		return err
+	}
+
+	if _, err := collectPipelineRefs(pipe); err != nil {
+		return err
+	}
+
+	triggers, err := validateTriggerBlocks(diff, pipe, meta.(gateConfig).client)
+	if err != nil {
+		return err
+	}
+
+	if err := validatePipelineParameters(pipe); err != nil {
+		return err
+	}
+
+	// The ValidateFunc of pipeline only sees the triggers in the JSON, so
+	// defaults are checked against trigger blocks here. CustomizeDiff
+	// cannot return warnings, so these only reach the log.
+	if triggers != nil {
+		checked := make(map[string]interface{}, len(pipe)+1)
+		for k, v := range pipe {
+			checked[k] = v
+		}
+		checked["triggers"] = triggers
+		for _, w := range checkTriggeredParameterDefaults(checked) {
+			log.Printf("[WARN] %s", w)
+		}
+	}
+
+	if meta.(gateConfig).validateExpressions {
+		if err := validateExpressions(pipe); err != nil {
+			return err
+		}
+	}
+
+	if diff.HasChanges("pipeline", "trigger", "locked", "disabled") {
+		// Saving the pipeline gives it a new updateTs and lastModifiedBy.
+		if err := diff.SetNewComputed("update_ts"); err != nil {
+			return err
+		}
+		if err := diff.SetNewComputed("last_modified_by"); err != nil {
+			return err
+		}
+
+		if !diff.NewValueKnown("trigger") || !diff.NewValueKnown("locked") || !diff.NewValueKnown("disabled") {
+			return diff.SetNewComputed("pipeline_summary")
+		}
+
+		summary, err := summarizePipelineAttributes(diff)
+		if err != nil {
+			return err
+		}
+		return diff.SetNew("pipeline_summary", summary)
+	}
+
+	return nil
+}
+
+// resourcePipelineDisabledCustomizeDiff plans the disabled flag. When the
+// disabled attribute is not set, the "disabled" key of the pipeline
+// definition decides, so a pipeline written as disabled stays disabled.
+// The pipeline is read from the raw configuration because pipelineStateFunc
+// strips the key from the planned value.
+func resourcePipelineDisabledCustomizeDiff(diff *schema.ResourceDiff) error {
+	raw := diff.GetRawConfig()
+	if raw.IsNull() {
+		return nil
+	}
+	rawPipeline := raw.GetAttr("pipeline")
+	if !rawPipeline.IsKnown() || rawPipeline.IsNull() {
+		return nil
+	}
+
+	pipe, err := decodePipeline(rawPipeline.AsString())
+	if err != nil {
+		return fmt.Errorf("could not decode pipeline: %s", err)
+	}
+	jsonDisabled, inJSON := pipe["disabled"].(bool)
+
+	if rawDisabled := raw.GetAttr("disabled"); !rawDisabled.IsNull() {
+		if rawDisabled.IsKnown() && inJSON && rawDisabled.True() != jsonDisabled {
+			return fmt.Errorf("disabled is %t but the pipeline definition sets \"disabled\" to %t; "+
+				"remove one of them", rawDisabled.True(), jsonDisabled)
+		}
+		return nil
+	}
+
+	// Without either setting, drift is left alone when it is ignored.
+	if !inJSON && diff.Get("ignore_disabled_drift").(bool) && diff.Id() != "" {
+		return nil
+	}
+	if diff.Id() == "" || diff.Get("disabled").(bool) != jsonDisabled {
+		return diff.SetNew("disabled", jsonDisabled)
+	}
+
+	return nil
+}
+
+// configuredPipelineDisabled returns the disabled flag the configuration
+// asks for: the disabled attribute when it is set, otherwise the
+// "disabled" key of the pipeline definition.
+func configuredPipelineDisabled(data *schema.ResourceData, pipe map[string]interface{}) bool {
+	if raw := data.GetRawConfig(); !raw.IsNull() {
+		if v := raw.GetAttr("disabled"); v.IsNull() {
+			disabled, _ := pipe["disabled"].(bool)
+			return disabled
+		}
+	}
+	return data.Get("disabled").(bool)
+}
+
 func resourcePipelineCreate(data *schema.ResourceData, meta interface{}) error {
 	clientConfig := meta.(gateConfig)
 	client := clientConfig.client
 	applicationName := data.Get("application").(string)
 	pipelineName := data.Get("name").(string)
-	pipeline := data.Get("pipeline").(string)
+	pipeline := pipelineConfigValue(data, "pipeline")
+
+	tmp, err := decodePipeline(pipeline)
+	if err != nil {
+		return err
+	}
 
-	var tmp map[string]interface{}
-	if err := json.NewDecoder(strings.NewReader(pipeline)).Decode(&tmp); err != nil {
+	if tmp, err = resolvePipelineRefs(tmp, client); err != nil {
 		return err
 	}
 
 	tmp["application"] = applicationName
 	tmp["name"] = pipelineName
 	delete(tmp, "id")
+	tmp["disabled"] = configuredPipelineDisabled(data, tmp)
+	applyPipelineLocked(data, tmp)
+
+	if pipelineTriggersManaged(data) {
+		if tmp["triggers"], err = expandPipelineTriggers(data, client); err != nil {
+			return err
+		}
+	}
 
 	if err := client.CreatePipeline(tmp); err != nil {
 		return err
@@ -69,16 +261,59 @@ func resourcePipelineRead(data *schema.ResourceData, meta interface{}) error {
 	applicationName := data.Get("application").(string)
 	pipelineName := data.Get("name").(string)
 
+	// After an apply the attributes still hold the applied change, which
+	// is summarized the same way it was planned, before they are
+	// overwritten below. Refreshes keep the summary of the last change.
+	if data.HasChanges("pipeline", "trigger", "locked", "disabled") {
+		summary, err := summarizePipelineAttributes(data)
+		if err != nil {
+			return err
+		}
+		if err := data.Set("pipeline_summary", summary); err != nil {
+			return fmt.Errorf("Could not set pipeline_summary for pipeline %s: %s", pipelineName, err)
+		}
+	}
+
 	var p pipelineRead
 	jsonMap, err := client.GetPipeline(applicationName, pipelineName, &p)
 	if err != nil {
 		return err
 	}
 
+	format, _ := data.Get("pipeline_format").(string)
+	if format == "" {
+		format = pipelineFormatJSON
+	}
+
+	// Capture these before editAndEncodePipeline strips them.
+	updateTs := stringValue(jsonMap["updateTs"])
+	lastModifiedBy := stringValue(jsonMap["lastModifiedBy"])
+	locked := flattenPipelineLocked(jsonMap["locked"])
+	disabled, _ := jsonMap["disabled"].(bool)
+
+	if pipelineTriggersManaged(data) {
+		triggers, err := flattenPipelineTriggers(jsonMap["triggers"], client)
+		if err != nil {
+			return err
+		}
+		if err := data.Set("trigger", triggers); err != nil {
+			return fmt.Errorf("Could not set trigger for pipeline %s: %s", pipelineName, err)
+		}
+		delete(jsonMap, "triggers")
+	}
+
+	if jsonMap, err = restoreConfiguredPipelineRefs(data, jsonMap, client); err != nil {
+		return err
+	}
+
 	pipeline, err := editAndEncodePipeline(jsonMap)
 	if err != nil {
 		return err
 	}
+	pipeline, err = encodePipelineAs(pipeline, format)
+	if err != nil {
+		return err
+	}
 	err = data.Set("pipeline", pipeline)
 	if err != nil {
 		return fmt.Errorf("Could not set pipeline for pipeline %s: %s", pipelineName, err)
@@ -89,6 +324,36 @@ func resourcePipelineRead(data *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Could not set pipeline_id for pipeline %s: %s", pipelineName, err)
 	}
 
+	err = data.Set("pipeline_format", format)
+	if err != nil {
+		return fmt.Errorf("Could not set pipeline_format for pipeline %s: %s", pipelineName, err)
+	}
+
+	err = data.Set("locked", locked)
+	if err != nil {
+		return fmt.Errorf("Could not set locked for pipeline %s: %s", pipelineName, err)
+	}
+
+	// With ignore_disabled_drift the state keeps the configured value, so
+	// a pipeline paused from Deck does not show up as a change to revert.
+	ignoreDisabledDrift, _ := data.Get("ignore_disabled_drift").(bool)
+	if !ignoreDisabledDrift || data.IsNewResource() {
+		err = data.Set("disabled", disabled)
+		if err != nil {
+			return fmt.Errorf("Could not set disabled for pipeline %s: %s", pipelineName, err)
+		}
+	}
+
+	err = data.Set("update_ts", updateTs)
+	if err != nil {
+		return fmt.Errorf("Could not set update_ts for pipeline %s: %s", pipelineName, err)
+	}
+
+	err = data.Set("last_modified_by", lastModifiedBy)
+	if err != nil {
+		return fmt.Errorf("Could not set last_modified_by for pipeline %s: %s", pipelineName, err)
+	}
+
 	data.SetId(p.ID)
 
 	return nil
@@ -100,22 +365,44 @@ func resourcePipelineUpdate(data *schema.ResourceData, meta interface{}) error {
 
 	applicationName := data.Get("application").(string)
 	pipelineName := data.Get("name").(string)
-	pipeline := data.Get("pipeline").(string)
+	pipeline := pipelineConfigValue(data, "pipeline")
 
 	pipelineID, ok := data.GetOk("pipeline_id")
 	if !ok {
 		return fmt.Errorf("No pipeline_id found to pipeline in %s with name %s", applicationName, pipelineName)
 	}
 
-	var pipe map[string]interface{}
-	err := json.Unmarshal([]byte(pipeline), &pipe)
+	pipe, err := decodePipeline(pipeline)
 	if err != nil {
 		return fmt.Errorf("could not unmarshal pipeline")
 	}
 
+	if pipe, err = resolvePipelineRefs(pipe, client); err != nil {
+		return err
+	}
+
+	if !data.Get("force_overwrite").(bool) {
+		if err := checkPipelineUnchanged(data, meta); err != nil {
+			return err
+		}
+	}
+
 	pipe["application"] = applicationName
 	pipe["name"] = pipelineName
 	pipe["id"] = pipelineID.(string)
+	applyPipelineLocked(data, pipe)
+
+	disabled, err := pipelineDisabledForUpdate(data, meta, pipe)
+	if err != nil {
+		return err
+	}
+	pipe["disabled"] = disabled
+
+	if pipelineTriggersManaged(data) {
+		if pipe["triggers"], err = expandPipelineTriggers(data, client); err != nil {
+			return err
+		}
+	}
 
 	if err := client.UpdatePipeline(pipelineID.(string), pipe); err != nil {
 		return err
@@ -123,6 +410,86 @@ func resourcePipelineUpdate(data *schema.ResourceData, meta interface{}) error {
 	return resourcePipelineRead(data, meta)
 }
 
+// pipelineDisabledForUpdate returns the disabled flag to save. When drift
+// is ignored and the configuration did not change it, the live value is
+// kept so updates do not re-enable a pipeline someone paused in Deck.
+func pipelineDisabledForUpdate(data *schema.ResourceData, meta interface{}, pipe map[string]interface{}) (bool, error) {
+	if !data.Get("ignore_disabled_drift").(bool) || data.HasChange("disabled") {
+		return configuredPipelineDisabled(data, pipe), nil
+	}
+
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+
+	var p pipelineRead
+	jsonMap, err := client.GetPipeline(data.Get("application").(string), data.Get("name").(string), &p)
+	if err != nil {
+		return false, err
+	}
+
+	disabled, _ := jsonMap["disabled"].(bool)
+	return disabled, nil
+}
+
+// restoreConfiguredPipelineRefs maps the IDs of pipelines referenced by
+// name in the last known pipeline definition back to their references.
+func restoreConfiguredPipelineRefs(data *schema.ResourceData, pipeline map[string]interface{}, client *gateclient.GatewayClient) (map[string]interface{}, error) {
+	known, _ := data.Get("pipeline").(string)
+	if known == "" {
+		return pipeline, nil
+	}
+
+	knownMap, err := decodePipeline(known)
+	if err != nil {
+		return pipeline, nil
+	}
+
+	refs, err := collectPipelineRefs(knownMap)
+	if err != nil {
+		return pipeline, nil
+	}
+
+	return restorePipelineRefs(pipeline, refs, client)
+}
+
+// checkPipelineUnchanged fails if the live pipeline was saved after our
+// last refresh, e.g. by someone editing it in Deck, so the update does not
+// silently discard their change.
+func checkPipelineUnchanged(data *schema.ResourceData, meta interface{}) error {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+
+	applicationName := data.Get("application").(string)
+	pipelineName := data.Get("name").(string)
+	// update_ts is unknown in the plan of an update, so compare against
+	// the value from our last refresh.
+	known, _ := data.GetChange("update_ts")
+	knownUpdateTs := known.(string)
+	if knownUpdateTs == "" {
+		return nil
+	}
+
+	var p pipelineRead
+	jsonMap, err := client.GetPipeline(applicationName, pipelineName, &p)
+	if err != nil {
+		return err
+	}
+
+	updateTs := stringValue(jsonMap["updateTs"])
+	if updateTs == "" || updateTs == knownUpdateTs {
+		return nil
+	}
+
+	lastModifiedBy := stringValue(jsonMap["lastModifiedBy"])
+	if lastModifiedBy == "" {
+		lastModifiedBy = "an unknown user"
+	}
+
+	return fmt.Errorf("Pipeline %s in %s was modified by %s at %s after it was last read by Terraform; "+
+		"refresh and review the plan again, or set force_overwrite = true to replace their changes",
+		pipelineName, applicationName, lastModifiedBy, formatUpdateTs(updateTs))
+}
+
 func resourcePipelineDelete(data *schema.ResourceData, meta interface{}) error {
 	clientConfig := meta.(gateConfig)
 	client := clientConfig.client
@@ -156,6 +523,76 @@ func resourcePipelineExists(data *schema.ResourceData, meta interface{}) (bool,
 	return true, nil
 }
 
+// pipelineConfigValue returns the pipeline in attribute key exactly as
+// written in the configuration. The value in state has been normalized by
+// pipelineStateFunc, which drops defaults Spinnaker would otherwise apply
+// differently, so it is not what we want to send to Gate.
+func pipelineConfigValue(data *schema.ResourceData, key string) string {
+	if raw := data.GetRawConfig(); !raw.IsNull() {
+		if v := raw.GetAttr(key); v.IsKnown() && !v.IsNull() {
+			return v.AsString()
+		}
+	}
+	return data.Get(key).(string)
+}
+
+// pipelineStateFunc stores the pipeline normalized and pretty-printed in
+// the format it was written in, so plans show a line by line diff of only
+// the fields that really changed.
+func pipelineStateFunc(v interface{}) string {
+	pipeline, err := decodeEditAndEncodePipeline(v.(string))
+	if err != nil {
+		return v.(string)
+	}
+
+	pipeline, err = encodePipelineAs(pipeline, detectPipelineFormat(v.(string)))
+	if err != nil {
+		return v.(string)
+	}
+	return pipeline
+}
+
+// detectPipelineFormat reports whether a pipeline was written as JSON or
+// YAML. Every JSON pipeline is an object, so anything else is YAML.
+func detectPipelineFormat(pipeline string) string {
+	if strings.HasPrefix(strings.TrimSpace(pipeline), "{") {
+		return pipelineFormatJSON
+	}
+	return pipelineFormatYAML
+}
+
+// decodePipeline decodes a JSON or YAML pipeline. JSON is a subset of
+// YAML, so both go through the YAML decoder.
+func decodePipeline(pipeline string) (map[string]interface{}, error) {
+	d, err := yaml.YAMLToJSON([]byte(pipeline))
+	if err != nil {
+		return nil, err
+	}
+
+	var pipelineMap map[string]interface{}
+	if err := json.Unmarshal(d, &pipelineMap); err != nil {
+		return nil, err
+	}
+	if pipelineMap == nil {
+		return nil, fmt.Errorf("pipeline must be a JSON or YAML object")
+	}
+
+	return pipelineMap, nil
+}
+
+// encodePipelineAs converts an encoded JSON pipeline to the given format.
+func encodePipelineAs(encodedPipeline, format string) (string, error) {
+	if format != pipelineFormatYAML {
+		return encodedPipeline, nil
+	}
+
+	raw, err := yaml.JSONToYAML([]byte(encodedPipeline))
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
 func pipelineDiffSuppressFunc(k, old, new string, d *schema.ResourceData) bool {
 	// Spinnaker does non-trivial modifications to the JSON for a pipeline,
 	// so we round-trip decode, edit, and encode the user's pipeline
@@ -176,31 +613,23 @@ func pipelineDiffSuppressFunc(k, old, new string, d *schema.ResourceData) bool {
 func decodeEditAndEncodePipeline(pipeline string) (encodedPipeline string, err error) {
 
 	// Decode the pipeline into a map we can edit
-	pipelineBytes := []byte(pipeline)
-	var pipelineMapGeneric interface{}
-	err = json.Unmarshal(pipelineBytes, &pipelineMapGeneric)
+	pipelineMap, err := decodePipeline(pipeline)
 	if err != nil {
 		return
 	}
 
-	pipelineMap := pipelineMapGeneric.(map[string]interface{})
-
 	return editAndEncodePipeline(pipelineMap)
 }
 
 func editAndEncodePipeline(pipelineMap map[string]interface{}) (encodedPipeline string, err error) {
 	// Remove the keys we know are problematic because they are managed
-	// by spinnaker or are handled by other schema attributes.
-	delete(pipelineMap, "application")
-	delete(pipelineMap, "lastModifiedBy")
-	delete(pipelineMap, "id")
-	delete(pipelineMap, "index")
-	delete(pipelineMap, "name")
-	delete(pipelineMap, "updateTs")
-
-	// Encode the pipeline into a single string
+	// by spinnaker, are handled by other schema attributes, or are
+	// defaults injected by Front50 and Deck.
+	normalizePipeline(pipelineMap)
+
+	// Encode the pipeline into an indented string
 	// This will sort all keys, etc.
-	editedPipelineBytes, err := json.Marshal(pipelineMap)
+	editedPipelineBytes, err := json.MarshalIndent(pipelineMap, "", "  ")
 	if err != nil {
 		return
 	}
@@ -208,3 +637,27 @@ func editAndEncodePipeline(pipelineMap map[string]interface{}) (encodedPipeline
 	encodedPipeline = string(editedPipelineBytes)
 	return
 }
+
+// stringValue renders a scalar from a decoded pipeline as a string.
+// Front50 returns updateTs as a string, but older versions used a number.
+func stringValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
+
+// formatUpdateTs renders an updateTs, in epoch milliseconds, as a time.
+func formatUpdateTs(updateTs string) string {
+	ms, err := strconv.ParseInt(updateTs, 10, 64)
+	if err != nil {
+		return updateTs
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+}
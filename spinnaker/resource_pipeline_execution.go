@@ -0,0 +1,266 @@
+package spinnaker
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/antihax/optional"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/rmullinnix461332/terraform-provider-spinnaker/gateclient"
+	gate "github.com/spinnaker/spin/gateapi"
+)
+
+const executionStatusSucceeded = "SUCCEEDED"
+
+// executionActiveStatuses are the statuses of an execution that has not
+// finished yet; every other status is terminal.
+var executionActiveStatuses = []string{"NOT_STARTED", "BUFFERED", "RUNNING", "PAUSED", "SUSPENDED"}
+
+var executionTerminalStatuses = []string{"SUCCEEDED", "FAILED_CONTINUE", "TERMINAL", "CANCELED", "STOPPED", "SKIPPED", "REDIRECT"}
+
+func resourcePipelineExecution() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"application": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateApplicationName,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"parameters": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"artifacts": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsJSON,
+			},
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"wait_for_completion": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"execution_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"outputs": {
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+		},
+		Create: resourcePipelineExecutionCreate,
+		Read:   resourcePipelineExecutionRead,
+		Update: resourcePipelineExecutionUpdate,
+		Delete: resourcePipelineExecutionDelete,
+	}
+}
+
+func resourcePipelineExecutionCreate(data *schema.ResourceData, meta interface{}) error {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+
+	// Finding the execution and waiting for it to finish share the create
+	// timeout.
+	deadline := time.Now().Add(data.Timeout(schema.TimeoutCreate))
+
+	applicationName := data.Get("application").(string)
+	pipelineName := data.Get("name").(string)
+
+	// Gate does not return the ID of the execution it starts, so the
+	// trigger carries an event ID to find the execution by.
+	eventID := id.UniqueId()
+	trigger := map[string]interface{}{
+		"type":    "manual",
+		"eventId": eventID,
+	}
+	if parameters := data.Get("parameters").(map[string]interface{}); len(parameters) > 0 {
+		trigger["parameters"] = parameters
+	}
+	if artifacts := data.Get("artifacts").(string); artifacts != "" {
+		var decoded []interface{}
+		if err := json.Unmarshal([]byte(artifacts), &decoded); err != nil {
+			return fmt.Errorf("artifacts must be a JSON list of artifacts: %s", err)
+		}
+		trigger["artifacts"] = decoded
+	}
+
+	if err := client.InvokePipeline(applicationName, pipelineName, trigger); err != nil {
+		if err.Error() == gateclient.ErrCodeNoSuchEntityException {
+			return fmt.Errorf("pipeline %q does not exist in application %q", pipelineName, applicationName)
+		}
+		return err
+	}
+
+	executionID, err := findExecutionByEventID(client, applicationName, pipelineName, eventID, time.Until(deadline))
+	if err != nil {
+		return err
+	}
+
+	data.SetId(executionID)
+	if err := data.Set("execution_id", executionID); err != nil {
+		return fmt.Errorf("Could not set execution_id for execution %s: %s", executionID, err)
+	}
+
+	if !data.Get("wait_for_completion").(bool) {
+		return resourcePipelineExecutionRead(data, meta)
+	}
+
+	wait := &retry.StateChangeConf{
+		Pending:    executionActiveStatuses,
+		Target:     executionTerminalStatuses,
+		Timeout:    time.Until(deadline),
+		Delay:      5 * time.Second,
+		MinTimeout: 5 * time.Second,
+		Refresh: func() (interface{}, string, error) {
+			execution, err := client.GetExecution(executionID)
+			if err != nil {
+				return nil, "", err
+			}
+			status, _ := execution["status"].(string)
+			return execution, status, nil
+		},
+	}
+	if _, err := wait.WaitForState(); err != nil {
+		return fmt.Errorf("waiting for execution %s of pipeline %s: %s", executionID, pipelineName, err)
+	}
+
+	if err := resourcePipelineExecutionRead(data, meta); err != nil {
+		return err
+	}
+
+	// The execution stays in state, so a failed execution taints the
+	// resource and is run again on the next apply.
+	if status := data.Get("status").(string); status != executionStatusSucceeded {
+		return fmt.Errorf("execution %s of pipeline %s finished with status %s", executionID, pipelineName, status)
+	}
+
+	return nil
+}
+
+// findExecutionByEventID waits for the execution started with eventID to
+// show up in the pipeline's execution history.
+func findExecutionByEventID(client *gateclient.GatewayClient, applicationName, pipelineName, eventID string, timeout time.Duration) (string, error) {
+	var executionID string
+
+	err := retry.Retry(timeout, func() *retry.RetryError {
+		executions, err := client.SearchPipelineExecutions(applicationName,
+			&gate.ExecutionsControllerApiSearchForPipelineExecutionsByTriggerUsingGETOpts{
+				PipelineName: optional.NewString(pipelineName),
+				EventId:      optional.NewString(eventID),
+			})
+		if err != nil {
+			return retry.NonRetryableError(err)
+		}
+		if len(executions) == 0 {
+			return retry.RetryableError(fmt.Errorf("execution of pipeline %s with event ID %s not found", pipelineName, eventID))
+		}
+		executionID, _ = executions[0]["id"].(string)
+		return nil
+	})
+
+	return executionID, err
+}
+
+func resourcePipelineExecutionRead(data *schema.ResourceData, meta interface{}) error {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+	executionID := data.Id()
+
+	execution, err := client.GetExecution(executionID)
+	if err != nil {
+		// Old executions are cleaned up by Spinnaker. That does not mean
+		// the pipeline should run again, so the last known state is kept.
+		if err.Error() == gateclient.ErrCodeNoSuchEntityException {
+			log.Printf("[WARN] execution %s no longer exists in Spinnaker", executionID)
+			return nil
+		}
+		return err
+	}
+
+	status, _ := execution["status"].(string)
+	if err := data.Set("status", status); err != nil {
+		return fmt.Errorf("Could not set status for execution %s: %s", executionID, err)
+	}
+
+	outputs, err := flattenExecutionOutputs(execution)
+	if err != nil {
+		return err
+	}
+	if err := data.Set("outputs", outputs); err != nil {
+		return fmt.Errorf("Could not set outputs for execution %s: %s", executionID, err)
+	}
+
+	if err := data.Set("execution_id", executionID); err != nil {
+		return fmt.Errorf("Could not set execution_id for execution %s: %s", executionID, err)
+	}
+
+	return nil
+}
+
+// Only wait_for_completion can change without re-running the pipeline,
+// and it only matters on create.
+func resourcePipelineExecutionUpdate(data *schema.ResourceData, meta interface{}) error {
+	return resourcePipelineExecutionRead(data, meta)
+}
+
+// Executions cannot be deleted; destroying the resource only removes it
+// from state and leaves a running execution alone.
+func resourcePipelineExecutionDelete(data *schema.ResourceData, meta interface{}) error {
+	data.SetId("")
+	return nil
+}
+
+// flattenExecutionOutputs merges the outputs of an execution's stages, in
+// stage order, so a later stage wins when two stages output the same key.
+// Values that are not strings are rendered as JSON.
+func flattenExecutionOutputs(execution map[string]interface{}) (map[string]interface{}, error) {
+	outputs := make(map[string]interface{})
+
+	stages, _ := execution["stages"].([]interface{})
+	for _, s := range stages {
+		stage, _ := s.(map[string]interface{})
+		stageOutputs, _ := stage["outputs"].(map[string]interface{})
+		for k, v := range stageOutputs {
+			if str, ok := v.(string); ok {
+				outputs[k] = str
+				continue
+			}
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("could not encode output %s: %s", k, err)
+			}
+			outputs[k] = string(encoded)
+		}
+	}
+
+	return outputs, nil
+}
@@ -0,0 +1,31 @@
+package spinnaker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenExecutionOutputs(t *testing.T) {
+	execution := decodeTestPipeline(t, `{
+		"status": "SUCCEEDED",
+		"stages": [
+			{"refId": "1", "outputs": {"image": "app:1.0", "replicas": 2}},
+			{"refId": "2"},
+			{"refId": "3", "outputs": {"image": "app:1.1", "endpoints": ["a", "b"]}}
+		]
+	}`)
+
+	outputs, err := flattenExecutionOutputs(execution)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"image":     "app:1.1",
+		"replicas":  "2",
+		"endpoints": `["a","b"]`,
+	}
+	if !reflect.DeepEqual(outputs, expected) {
+		t.Fatalf("expected outputs %v, got %v", expected, outputs)
+	}
+}
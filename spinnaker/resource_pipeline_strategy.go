@@ -0,0 +1,243 @@
+package spinnaker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/rmullinnix461332/terraform-provider-spinnaker/gateclient"
+)
+
+func resourcePipelineStrategy() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"application": {
+				Type:         schema.TypeString,
+				ForceNew:     true,
+				Required:     true,
+				ValidateFunc: validateApplicationName,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				ForceNew: true,
+				Required: true,
+			},
+			"strategy": {
+				Type:             schema.TypeString,
+				Required:         true,
+				DiffSuppressFunc: strategyDiffSuppressFunc,
+				StateFunc:        strategyStateFunc,
+			},
+			"strategy_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"strategy_format": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Create:        resourcePipelineStrategyCreate,
+		Read:          resourcePipelineStrategyRead,
+		Update:        resourcePipelineStrategyUpdate,
+		Delete:        resourcePipelineStrategyDelete,
+		Exists:        resourcePipelineStrategyExists,
+		CustomizeDiff: resourcePipelineStrategyCustomizeDiff,
+	}
+}
+
+func resourcePipelineStrategyCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	if !diff.NewValueKnown("strategy") {
+		return nil
+	}
+
+	strategy, err := decodePipeline(diff.Get("strategy").(string))
+	if err != nil {
+		return fmt.Errorf("could not decode strategy: %s", err)
+	}
+
+	if err := diff.SetNew("strategy_format", detectPipelineFormat(diff.Get("strategy").(string))); err != nil {
+		return err
+	}
+
+	return validateStageGraph(strategy)
+}
+
+// expandPipelineStrategy builds the payload for a strategy. Spinnaker
+// tells strategies apart from pipelines by their "strategy" flag.
+func expandPipelineStrategy(data *schema.ResourceData) (map[string]interface{}, error) {
+	strategy, err := decodePipeline(pipelineConfigValue(data, "strategy"))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode strategy: %s", err)
+	}
+
+	strategy["application"] = data.Get("application").(string)
+	strategy["name"] = data.Get("name").(string)
+	strategy["strategy"] = true
+
+	return strategy, nil
+}
+
+// flattenPipelineStrategy encodes a strategy read from Spinnaker in the
+// given format, normalized the same way as the strategy attribute.
+func flattenPipelineStrategy(strategy map[string]interface{}, format string) (string, error) {
+	encoded, err := editAndEncodeStrategy(strategy)
+	if err != nil {
+		return "", err
+	}
+	return encodePipelineAs(encoded, format)
+}
+
+// editAndEncodeStrategy is editAndEncodePipeline for strategies. The
+// "strategy" flag is always set by expandPipelineStrategy, so it is
+// dropped whether or not the definition includes it, as Deck exports do.
+func editAndEncodeStrategy(strategy map[string]interface{}) (string, error) {
+	delete(strategy, "strategy")
+	return editAndEncodePipeline(strategy)
+}
+
+func decodeEditAndEncodeStrategy(strategy string) (string, error) {
+	strategyMap, err := decodePipeline(strategy)
+	if err != nil {
+		return "", err
+	}
+	return editAndEncodeStrategy(strategyMap)
+}
+
+// strategyStateFunc is pipelineStateFunc for strategies.
+func strategyStateFunc(v interface{}) string {
+	strategy, err := decodeEditAndEncodeStrategy(v.(string))
+	if err != nil {
+		return v.(string)
+	}
+
+	strategy, err = encodePipelineAs(strategy, detectPipelineFormat(v.(string)))
+	if err != nil {
+		return v.(string)
+	}
+	return strategy
+}
+
+// strategyDiffSuppressFunc is pipelineDiffSuppressFunc for strategies.
+func strategyDiffSuppressFunc(k, old, new string, d *schema.ResourceData) bool {
+	editedOld, err := decodeEditAndEncodeStrategy(old)
+	if err != nil {
+		return false
+	}
+
+	editedNew, err := decodeEditAndEncodeStrategy(new)
+	if err != nil {
+		return false
+	}
+
+	return editedOld == editedNew
+}
+
+func resourcePipelineStrategyCreate(data *schema.ResourceData, meta interface{}) error {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+
+	strategy, err := expandPipelineStrategy(data)
+	if err != nil {
+		return err
+	}
+	delete(strategy, "id")
+
+	if err := client.CreatePipelineConfig(gateclient.KindStrategy, strategy); err != nil {
+		return err
+	}
+
+	return resourcePipelineStrategyRead(data, meta)
+}
+
+func resourcePipelineStrategyRead(data *schema.ResourceData, meta interface{}) error {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+	applicationName := data.Get("application").(string)
+	strategyName := data.Get("name").(string)
+
+	var p pipelineRead
+	jsonMap, err := client.GetPipelineConfig(gateclient.KindStrategy, applicationName, strategyName, &p)
+	if err != nil {
+		return err
+	}
+
+	format, _ := data.Get("strategy_format").(string)
+	if format == "" {
+		format = pipelineFormatJSON
+	}
+
+	strategy, err := flattenPipelineStrategy(jsonMap, format)
+	if err != nil {
+		return err
+	}
+	err = data.Set("strategy", strategy)
+	if err != nil {
+		return fmt.Errorf("Could not set strategy for strategy %s: %s", strategyName, err)
+	}
+
+	err = data.Set("strategy_id", p.ID)
+	if err != nil {
+		return fmt.Errorf("Could not set strategy_id for strategy %s: %s", strategyName, err)
+	}
+
+	err = data.Set("strategy_format", format)
+	if err != nil {
+		return fmt.Errorf("Could not set strategy_format for strategy %s: %s", strategyName, err)
+	}
+
+	data.SetId(p.ID)
+
+	return nil
+}
+
+func resourcePipelineStrategyUpdate(data *schema.ResourceData, meta interface{}) error {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+
+	strategyID, ok := data.GetOk("strategy_id")
+	if !ok {
+		return fmt.Errorf("No strategy_id found to strategy in %s with name %s",
+			data.Get("application").(string), data.Get("name").(string))
+	}
+
+	strategy, err := expandPipelineStrategy(data)
+	if err != nil {
+		return err
+	}
+	strategy["id"] = strategyID.(string)
+
+	if err := client.UpdatePipelineConfig(gateclient.KindStrategy, strategyID.(string), strategy); err != nil {
+		return err
+	}
+
+	return resourcePipelineStrategyRead(data, meta)
+}
+
+func resourcePipelineStrategyDelete(data *schema.ResourceData, meta interface{}) error {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+
+	applicationName := data.Get("application").(string)
+	strategyName := data.Get("name").(string)
+
+	return client.DeletePipelineConfig(gateclient.KindStrategy, applicationName, strategyName)
+}
+
+func resourcePipelineStrategyExists(data *schema.ResourceData, meta interface{}) (bool, error) {
+	clientConfig := meta.(gateConfig)
+	client := clientConfig.client
+
+	applicationName := data.Get("application").(string)
+	strategyName := data.Get("name").(string)
+
+	var p pipelineRead
+	if _, err := client.GetPipelineConfig(gateclient.KindStrategy, applicationName, strategyName, &p); err != nil {
+		if err.Error() == gateclient.ErrCodeNoSuchEntityException {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return p.Name != "", nil
+}
@@ -0,0 +1,68 @@
+package spinnaker
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestExpandPipelineStrategy(t *testing.T) {
+	data := schema.TestResourceDataRaw(t, resourcePipelineStrategy().Schema, map[string]interface{}{
+		"application": "app",
+		"name":        "redblack",
+		"strategy":    `{"stages": [{"refId": "1", "type": "wait", "name": "Wait"}]}`,
+	})
+
+	strategy, err := expandPipelineStrategy(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strategy["application"] != "app" || strategy["name"] != "redblack" || strategy["strategy"] != true {
+		t.Fatalf("expected application, name and the strategy flag to be set, got %v", strategy)
+	}
+	if stages, _ := strategy["stages"].([]interface{}); len(stages) != 1 {
+		t.Fatalf("expected the stages to be kept, got %v", strategy["stages"])
+	}
+}
+
+func TestFlattenPipelineStrategy(t *testing.T) {
+	strategy := decodeTestPipeline(t, `{
+		"application": "app",
+		"id": "3d1f1a4e",
+		"name": "redblack",
+		"strategy": true,
+		"updateTs": "1700000000000",
+		"stages": [{"refId": "1", "type": "wait", "name": "Wait"}]
+	}`)
+
+	flattened, err := flattenPipelineStrategy(strategy, pipelineFormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `stages:
+- name: Wait
+  refId: "1"
+  type: wait
+`
+	if flattened != expected {
+		t.Fatalf("expected strategy:\n%s\ngot:\n%s", expected, flattened)
+	}
+}
+
+func TestStrategyFlagIsNormalized(t *testing.T) {
+	withFlag := `{"strategy": true, "stages": [{"refId": "1", "type": "wait"}]}`
+	withoutFlag := `{"stages": [{"refId": "1", "type": "wait"}]}`
+
+	if !strategyDiffSuppressFunc("strategy", withoutFlag, withFlag, nil) {
+		t.Fatal("expected the strategy flag not to cause a diff")
+	}
+	if strategyStateFunc(withFlag) != strategyStateFunc(withoutFlag) {
+		t.Fatalf("expected the same state with and without the strategy flag, got:\n%s\nand:\n%s",
+			strategyStateFunc(withFlag), strategyStateFunc(withoutFlag))
+	}
+	if strategyDiffSuppressFunc("strategy", withoutFlag, `{"stages": [{"refId": "1", "type": "manualJudgment"}]}`, nil) {
+		t.Fatal("expected a changed stage to cause a diff")
+	}
+}
@@ -2,12 +2,17 @@ package spinnaker
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/ghodss/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rmullinnix461332/terraform-provider-spinnaker/gateclient"
 )
@@ -18,17 +23,33 @@ func resourcePipelineTemplate() *schema.Resource {
 			"name": {
 				Type:         schema.TypeString,
 				Required:     true,
+				ForceNew:     true,
 				ValidateFunc: validateTemplateName,
 			},
 			"template": {
 				Type:             schema.TypeString,
 				Required:         true,
 				DiffSuppressFunc: suppressEquivalentPipelineTemplateDiffs,
+				ValidateFunc:     validatePipelineTemplateExpressions,
+			},
+			"tag": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateTemplateTag,
 			},
 			"url": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"digest": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"force_delete": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
 		},
 		Create: resourcePipelineTemplateCreate,
 		Read:   resourcePipelineTemplateRead,
@@ -38,35 +59,101 @@ func resourcePipelineTemplate() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		CustomizeDiff: customdiff.All(
+			resourcePipelineTemplateIDCustomizeDiff,
+			resourcePipelineTemplateCustomizeDiff,
+		),
+	}
+}
+
+// resourcePipelineTemplateIDCustomizeDiff fails when the id inside the
+// template disagrees with name. name is the template's ID, so a different
+// id would otherwise be silently replaced when the template is saved.
+func resourcePipelineTemplateIDCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	if !diff.NewValueKnown("template") || !diff.NewValueKnown("name") {
+		return nil
+	}
+
+	var t map[string]interface{}
+	if err := yaml.Unmarshal([]byte(diff.Get("template").(string)), &t); err != nil {
+		return fmt.Errorf("Error decoding template: %s", err.Error())
+	}
+
+	return checkPipelineTemplateID(t, diff.Get("name").(string))
+}
+
+func checkPipelineTemplateID(t map[string]interface{}, name string) error {
+	if id, ok := t["id"]; ok && stringValue(id) != name {
+		return fmt.Errorf("template id %q does not match name %q; remove id from template or set it to %q",
+			stringValue(id), name, name)
+	}
+	return nil
+}
+
+func resourcePipelineTemplateCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	if !diff.NewValueKnown("template") {
+		return nil
+	}
+
+	var t map[string]interface{}
+	if err := yaml.Unmarshal([]byte(diff.Get("template").(string)), &t); err != nil {
+		return fmt.Errorf("Error decoding template: %s", err.Error())
+	}
+
+	switch templateSchema(t) {
+	case templateSchemaV2:
+	case templateSchemaV1:
+		if diff.Get("tag").(string) != "" {
+			return fmt.Errorf("tag is not supported by v1 pipeline templates")
+		}
+	default:
+		return fmt.Errorf("template schema must be %q or %q", templateSchemaV2, templateSchemaV1)
+	}
+
+	if meta.(gateConfig).validateExpressions {
+		return validateExpressions(t)
+	}
+
+	return nil
+}
+
+// validatePipelineTemplateExpressions is the ValidateFunc of template. Like
+// validatePipelineExpressions, it also warns about references to parameters
+// the template does not declare.
+func validatePipelineTemplateExpressions(v interface{}, k string) ([]string, []error) {
+	t, err := decodePipeline(v.(string))
+	if err != nil {
+		return nil, nil
 	}
+
+	parameters := make(map[string]bool)
+	if pipeline, ok := t["pipeline"].(map[string]interface{}); ok {
+		parameters = declaredParameters(pipeline)
+	} else if configuration, ok := t["configuration"].(map[string]interface{}); ok {
+		parameters = declaredParameters(map[string]interface{}{"parameterConfig": configuration["parameters"]})
+	}
+
+	return expressionWarnings(t, parameters), nil
 }
 
 func resourcePipelineTemplateCreate(data *schema.ResourceData, meta interface{}) error {
 	clientConfig := meta.(gateConfig)
 	client := clientConfig.client
 
-	template := data.Get("template").(string)
 	templateName := data.Get("name").(string)
 
-	d, err := yaml.YAMLToJSON([]byte(template))
+	jsonContent, err := expandPipelineTemplate(data)
 	if err != nil {
 		return err
 	}
 
-	var jsonContent map[string]interface{}
-	if err = json.NewDecoder(bytes.NewReader(d)).Decode(&jsonContent); err != nil {
-		return fmt.Errorf("Error decoding json: %s", err.Error())
-	}
-
-	if _, ok := jsonContent["schema"]; !ok {
-		return fmt.Errorf("Pipeline save command currently only supports pipeline template configurations")
-	}
-
-	jsonContent["id"] = templateName
-	//templateName := jsonContent["id"].(string)
-
 	log.Println("[DEBUG] Making request to spinnaker")
-	if err := client.CreatePipelineTemplate(jsonContent); err != nil {
+	if templateSchema(jsonContent) == templateSchemaV1 {
+		err = client.CreatePipelineTemplateV1(jsonContent)
+	} else {
+		err = client.CreatePipelineTemplate(jsonContent, data.Get("tag").(string))
+	}
+	if err != nil {
 		log.Printf("[DEBUG] Error response from spinnaker: %s", err.Error())
 		return err
 	}
@@ -78,14 +165,37 @@ func resourcePipelineTemplateCreate(data *schema.ResourceData, meta interface{})
 	return nil
 }
 
+// expandPipelineTemplate decodes the template for saving, with its id set
+// to name.
+func expandPipelineTemplate(data *schema.ResourceData) (map[string]interface{}, error) {
+	d, err := yaml.YAMLToJSON([]byte(data.Get("template").(string)))
+	if err != nil {
+		return nil, err
+	}
+
+	var jsonContent map[string]interface{}
+	if err = json.NewDecoder(bytes.NewReader(d)).Decode(&jsonContent); err != nil {
+		return nil, fmt.Errorf("Error decoding json: %s", err.Error())
+	}
+
+	if schema := templateSchema(jsonContent); schema != templateSchemaV2 && schema != templateSchemaV1 {
+		return nil, fmt.Errorf("template schema must be %q or %q", templateSchemaV2, templateSchemaV1)
+	}
+
+	jsonContent["id"] = data.Get("name").(string)
+
+	return jsonContent, nil
+}
+
 func resourcePipelineTemplateRead(data *schema.ResourceData, meta interface{}) error {
 	clientConfig := meta.(gateConfig)
 	client := clientConfig.client
 
 	templateName := data.Id()
+	tag := data.Get("tag").(string)
 
-	t := make(map[string]interface{})
-	if err := client.GetPipelineTemplate(templateName, &t); err != nil {
+	t, err := getPipelineTemplate(client, templateName, tag, pipelineTemplateV1(data))
+	if err != nil {
 		if err.Error() == gateclient.ErrCodeNoSuchEntityException {
 			data.SetId("")
 			return nil
@@ -93,9 +203,13 @@ func resourcePipelineTemplateRead(data *schema.ResourceData, meta interface{}) e
 		return err
 	}
 
-	// Remove timestamp from response
+	digest, _ := t["digest"].(string)
+
+	// Remove timestamp and version from response
 	delete(t, "updateTs")
 	delete(t, "lastModifiedBy")
+	delete(t, "tag")
+	delete(t, "digest")
 
 	jsonContent, err := json.Marshal(t)
 	if err != nil {
@@ -108,7 +222,8 @@ func resourcePipelineTemplateRead(data *schema.ResourceData, meta interface{}) e
 	}
 	data.Set("name", templateName)
 	data.Set("template", string(raw))
-	data.Set("url", fmt.Sprintf("spinnaker://%s", t["id"].(string)))
+	data.Set("url", pipelineTemplateURL(stringValue(t["id"]), tag))
+	data.Set("digest", digest)
 	data.SetId(templateName)
 
 	return nil
@@ -117,26 +232,19 @@ func resourcePipelineTemplateRead(data *schema.ResourceData, meta interface{}) e
 func resourcePipelineTemplateUpdate(data *schema.ResourceData, meta interface{}) error {
 	clientConfig := meta.(gateConfig)
 	client := clientConfig.client
-	var templateName string
-	template := data.Get("template").(string)
+	templateName := data.Get("name").(string)
 
-	d, err := yaml.YAMLToJSON([]byte(template))
+	jsonContent, err := expandPipelineTemplate(data)
 	if err != nil {
 		return err
 	}
 
-	var jsonContent map[string]interface{}
-	if err = json.NewDecoder(bytes.NewReader(d)).Decode(&jsonContent); err != nil {
-		return fmt.Errorf("Error decoding json: %s", err.Error())
-	}
-
-	if _, ok := jsonContent["schema"]; !ok {
-		return fmt.Errorf("Pipeline save command currently only supports pipeline template configurations")
+	if templateSchema(jsonContent) == templateSchemaV1 {
+		err = client.UpdatePipelineTemplateV1(templateName, jsonContent)
+	} else {
+		err = client.UpdatePipelineTemplate(templateName, data.Get("tag").(string), jsonContent)
 	}
-
-	templateName = jsonContent["id"].(string)
-
-	if err := client.UpdatePipelineTemplate(templateName, jsonContent); err != nil {
+	if err != nil {
 		return err
 	}
 
@@ -148,8 +256,21 @@ func resourcePipelineTemplateDelete(data *schema.ResourceData, meta interface{})
 	clientConfig := meta.(gateConfig)
 	client := clientConfig.client
 	templateName := data.Id()
+	v1 := pipelineTemplateV1(data)
+
+	if !data.Get("force_delete").(bool) {
+		if err := checkPipelineTemplateUnused(client, templateName, v1); err != nil {
+			return err
+		}
+	}
 
-	if err := client.DeletePipelineTemplate(templateName); err != nil {
+	var err error
+	if v1 {
+		err = client.DeletePipelineTemplateV1(templateName)
+	} else {
+		err = client.DeletePipelineTemplate(templateName, data.Get("tag").(string))
+	}
+	if err != nil {
 		return err
 	}
 
@@ -157,26 +278,118 @@ func resourcePipelineTemplateDelete(data *schema.ResourceData, meta interface{})
 	return nil
 }
 
+// checkPipelineTemplateUnused fails if any pipeline still uses the
+// template, since deleting it would break those pipelines.
+func checkPipelineTemplateUnused(client *gateclient.GatewayClient, templateName string, v1 bool) error {
+	getDependents := client.GetPipelineTemplateDependents
+	if v1 {
+		getDependents = client.GetPipelineTemplateDependentsV1
+	}
+
+	dependents, err := getDependents(templateName)
+	if err != nil {
+		if err.Error() == gateclient.ErrCodeNoSuchEntityException {
+			return nil
+		}
+		return err
+	}
+
+	if len(dependents) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("Pipeline template %s is still used by %s; "+
+		"remove them first, or set force_delete = true to delete the template anyway",
+		templateName, strings.Join(pipelineTemplateDependentNames(dependents), ", "))
+}
+
+// pipelineTemplateDependentNames returns the dependents of a template as
+// sorted application/pipeline pairs.
+func pipelineTemplateDependentNames(dependents []map[string]interface{}) []string {
+	names := make([]string, 0, len(dependents))
+	for _, d := range dependents {
+		names = append(names, fmt.Sprintf("%s/%s", stringValue(d["application"]), stringValue(d["name"])))
+	}
+	sort.Strings(names)
+	return names
+}
+
 func resourcePipelineTemplateExists(data *schema.ResourceData, meta interface{}) (bool, error) {
 	clientConfig := meta.(gateConfig)
 	client := clientConfig.client
 	templateName := data.Id()
 
-	t := &templateRead{}
-	if err := client.GetPipelineTemplate(templateName, t); err != nil {
+	t, err := getPipelineTemplate(client, templateName, data.Get("tag").(string), pipelineTemplateV1(data))
+	if err != nil {
 		if err.Error() == gateclient.ErrCodeNoSuchEntityException {
 			return false, nil
 		}
 		return false, err
 	}
 
-	if t.ID == templateName {
+	if stringValue(t["id"]) == templateName {
 		return true, nil
 	}
 
 	return false, nil
 }
 
+// pipelineTemplateV1 reports whether the template in state is a v1
+// template. It is false when there is no template in state yet, e.g. on
+// import.
+func pipelineTemplateV1(data *schema.ResourceData) bool {
+	var t map[string]interface{}
+	if err := yaml.Unmarshal([]byte(data.Get("template").(string)), &t); err != nil {
+		return false
+	}
+	return templateSchema(t) == templateSchemaV1
+}
+
+// getPipelineTemplate reads a template through the API for its schema.
+// Templates not known to be v1 are looked up as v2 templates first, so
+// that imports find either kind.
+func getPipelineTemplate(client *gateclient.GatewayClient, templateName, tag string, v1 bool) (map[string]interface{}, error) {
+	t := make(map[string]interface{})
+	if !v1 {
+		err := client.GetPipelineTemplate(templateName, tag, &t)
+		if err == nil || err.Error() != gateclient.ErrCodeNoSuchEntityException || tag != "" {
+			return t, err
+		}
+	}
+
+	if err := client.GetPipelineTemplateV1(templateName, &t); err != nil {
+		return nil, err
+	}
+	if templateSchema(t) != templateSchemaV1 {
+		return nil, fmt.Errorf(gateclient.ErrCodeNoSuchEntityException)
+	}
+
+	return t, nil
+}
+
+// pipelineTemplateURLPattern matches the references pipelines use to pick
+// a template from Front50.
+var pipelineTemplateURLPattern = regexp.MustCompile(`^spinnaker://[a-zA-Z0-9-]+(:[a-zA-Z0-9._-]+)?$`)
+
+// pipelineTemplateReference is the template block of a templated pipeline
+// that picks the template at reference.
+func pipelineTemplateReference(reference string) map[string]interface{} {
+	return map[string]interface{}{
+		"artifactAccount": "front50ArtifactCredentials",
+		"reference":       reference,
+		"type":            "front50/pipelineTemplate",
+	}
+}
+
+// pipelineTemplateURL is the reference pipelines use to pick a template,
+// pinned to a tag when one is given.
+func pipelineTemplateURL(templateID, tag string) string {
+	if tag == "" {
+		return fmt.Sprintf("spinnaker://%s", templateID)
+	}
+	return fmt.Sprintf("spinnaker://%s:%s", templateID, tag)
+}
+
 func suppressEquivalentPipelineTemplateDiffs(k, old, new string, d *schema.ResourceData) bool {
 	equivalent, err := areEqualJSON(old, new)
 
@@ -210,5 +423,13 @@ func areEqualJSON(s1, s2 string) (bool, error) {
 	bytesB, _ := json.Marshal(o2)
 	_ = json.Unmarshal(bytesB, &x2)
 
+	// The id is always taken from name, so it does not matter here.
+	if m, ok := x1.(map[string]interface{}); ok {
+		delete(m, "id")
+	}
+	if m, ok := x2.(map[string]interface{}); ok {
+		delete(m, "id")
+	}
+
 	return reflect.DeepEqual(x1, x2), nil
 }
@@ -2,24 +2,53 @@ package spinnaker
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/ghodss/yaml"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/rmullinnix461332/terraform-provider-spinnaker/gateclient"
 )
 
 func resourcePipelineTemplateConfig() *schema.Resource {
+	return &schema.Resource{
+		Schema: resourcePipelineTemplateConfigSchema(),
+		Create: resourcePipelineTemplateConfigCreate,
+		Read:   resourcePipelineTemplateConfigRead,
+		Update: resourcePipelineTemplateConfigUpdate,
+		Delete: resourcePipelineTemplateConfigDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourcePipelineTemplateConfigImport,
+		},
+		CustomizeDiff: resourcePipelineTemplateConfigCustomizeDiff,
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Type:    resourcePipelineTemplateConfigV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourcePipelineTemplateConfigStateUpgradeV0,
+				Version: 0,
+			},
+		},
+	}
+}
+
+// resourcePipelineTemplateConfigV0 is the resource before IDs moved from
+// "<application>:<name>" to templateConfigID. It is a frozen copy of the
+// schema at that version, so later changes to the schema do not change
+// how old state is decoded.
+func resourcePipelineTemplateConfigV0() *schema.Resource {
 	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"pipeline_config": {
-				Type:             schema.TypeString,
-				Required:         true,
-				DiffSuppressFunc: suppressEquivalentPipelineConfigDiffs,
+				Type:     schema.TypeString,
+				Required: true,
 			},
 			"application": {
 				Type:     schema.TypeString,
@@ -31,21 +60,282 @@ func resourcePipelineTemplateConfig() *schema.Resource {
 				ForceNew: true,
 			},
 		},
-		Create: resourcePipelineTemplateConfigCreate,
-		Read:   resourcePipelineTemplateConfigRead,
-		Update: resourcePipelineTemplateConfigUpdate,
-		Delete: resourcePipelineTemplateConfigDelete,
-		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+	}
+}
+
+func resourcePipelineTemplateConfigSchema() map[string]*schema.Schema {
+	s := map[string]*schema.Schema{
+		"pipeline_config": {
+			Type:             schema.TypeString,
+			Optional:         true,
+			ExactlyOneOf:     []string{"pipeline_config", "template_reference"},
+			DiffSuppressFunc: suppressEquivalentPipelineConfigDiffs,
+			ValidateFunc:     validation.All(validatePipelineExpressions, validateTemplateConfigParameterDefaults),
 		},
+		"application": {
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"template_name": {
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
+		"locked": lockedSchema(),
+	}
+	for k, v := range templateConfigSchema() {
+		s[k] = v
+	}
+
+	return s
+}
+
+// templateConfigIDEscaper escapes the separator of templateConfigID, and
+// the escape character itself, so pipeline names may contain either.
+var templateConfigIDEscaper = strings.NewReplacer("%", "%25", "/", "%2F")
+
+// templateConfigIDPattern matches the IDs Front50 gives pipelines.
+var templateConfigIDPattern = regexp.MustCompile(
+	`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// templateConfigID is the ID of a templated pipeline in state,
+// "<application>/<name>" with "/" and "%" in the name escaped.
+func templateConfigID(application, name string) string {
+	return templateConfigIDEscaper.Replace(application) + "/" + templateConfigIDEscaper.Replace(name)
+}
+
+// parseTemplateConfigID splits an ID made by templateConfigID.
+func parseTemplateConfigID(id string) (string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("Invalid pipeline template config ID %q, expected <application>/<pipeline name>", id)
 	}
+
+	application, err := url.PathUnescape(parts[0])
+	if err != nil {
+		return "", "", fmt.Errorf("Invalid application in pipeline template config ID %q: %s", id, err)
+	}
+	name, err := url.PathUnescape(parts[1])
+	if err != nil {
+		return "", "", fmt.Errorf("Invalid pipeline name in pipeline template config ID %q: %s", id, err)
+	}
+
+	if application == "" || name == "" {
+		return "", "", fmt.Errorf("Invalid pipeline template config ID %q, expected <application>/<pipeline name>", id)
+	}
+	if _, errs := validateApplicationName(application, "application"); len(errs) > 0 {
+		return "", "", fmt.Errorf("Invalid pipeline template config ID %q: %s", id, errs[0])
+	}
+
+	return application, name, nil
+}
+
+// resourcePipelineTemplateConfigStateUpgradeV0 moves IDs from
+// "<application>:<name>" to templateConfigID.
+func resourcePipelineTemplateConfigStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	if rawState == nil {
+		return rawState, nil
+	}
+
+	application, _ := rawState["application"].(string)
+	name, _ := rawState["template_name"].(string)
+	if application == "" || name == "" {
+		// Application names cannot contain ":", so the first one is
+		// always the separator.
+		id, _ := rawState["id"].(string)
+		parts := strings.SplitN(id, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Could not upgrade pipeline template config ID %q", id)
+		}
+		application, name = parts[0], parts[1]
+	}
+
+	rawState["id"] = templateConfigID(application, name)
+
+	return rawState, nil
+}
+
+// resourcePipelineTemplateConfigImport accepts "<application>/<name>", the
+// old "<application>:<name>" form, or the ID Front50 gave the pipeline.
+func resourcePipelineTemplateConfigImport(ctx context.Context, data *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	id := data.Id()
+
+	var application, name string
+	switch {
+	case templateConfigIDPattern.MatchString(id):
+		client := meta.(gateConfig).client
+
+		p := PipelineConfig{}
+		if _, err := client.GetPipelineByID(id, &p); err != nil {
+			if err.Error() == gateclient.ErrCodeNoSuchEntityException {
+				return nil, fmt.Errorf("No pipeline found with ID %s", id)
+			}
+			return nil, err
+		}
+		if p.Type != "templatedPipeline" {
+			return nil, fmt.Errorf("Pipeline %s (%s/%s) is not a templated pipeline", id, p.Application, p.Name)
+		}
+		application, name = p.Application, p.Name
+	case !strings.Contains(id, "/") && strings.Contains(id, ":"):
+		parts := strings.SplitN(id, ":", 2)
+		application, name = parts[0], parts[1]
+		if application == "" || name == "" {
+			return nil, fmt.Errorf("Invalid pipeline template config ID %q, expected <application>/<pipeline name>", id)
+		}
+	default:
+		var err error
+		if application, name, err = parseTemplateConfigID(id); err != nil {
+			return nil, fmt.Errorf("%s, or the ID of the pipeline", err)
+		}
+	}
+
+	data.Set("application", application)
+	data.Set("template_name", name)
+	data.SetId(templateConfigID(application, name))
+
+	return []*schema.ResourceData{data}, nil
+}
+
+func resourcePipelineTemplateConfigCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+	var config map[string]interface{}
+	if templateConfigStructured(diff) {
+		if !diff.NewValueKnown("variables") || !diff.NewValueKnown("template_reference") {
+			return nil
+		}
+
+		var err error
+		if config, err = expandTemplateConfig(diff, meta.(gateConfig).client); err != nil {
+			return err
+		}
+	} else {
+		if !diff.NewValueKnown("pipeline_config") {
+			return nil
+		}
+
+		if err := yaml.Unmarshal([]byte(diff.Get("pipeline_config").(string)), &config); err != nil {
+			return fmt.Errorf("Error decoding pipeline config: %s", err.Error())
+		}
+	}
+
+	if templateSchema(config) == templateSchemaV1 {
+		if client := meta.(gateConfig).client; client != nil {
+			if err := validateTemplateConfigV1Variables(client, config); err != nil {
+				return err
+			}
+		}
+	} else if client := meta.(gateConfig).client; client != nil {
+		if err := validateTemplateConfigVariables(client, config); err != nil {
+			return err
+		}
+	}
+
+	// pipeline_config is checked by its ValidateFunc, but the structured
+	// attributes are spread over several blocks that no ValidateFunc sees
+	// together. CustomizeDiff cannot return warnings, so these only reach
+	// the log.
+	if templateConfigStructured(diff) {
+		for _, w := range checkTriggeredParameterDefaults(config) {
+			log.Printf("[WARN] %s", w)
+		}
+	}
+
+	if meta.(gateConfig).validateExpressions {
+		return validateExpressions(config)
+	}
+
+	return nil
+}
+
+// validateTemplateConfigParameterDefaults is the ValidateFunc that warns
+// about required parameters without a default in a pipeline_config with
+// automated triggers. Parameters are usually declared and consumed by the
+// referenced template rather than the config, so this is the only
+// parameter check that makes sense here. v1 configs keep their parameters
+// and triggers under configuration, so they are checked in the v2 form.
+func validateTemplateConfigParameterDefaults(v interface{}, k string) ([]string, []error) {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(v.(string)), &config); err != nil {
+		return nil, nil
+	}
+
+	if templateSchema(config) == templateSchemaV1 {
+		var warnings []string
+		config, warnings = convertTemplateConfigV1(config)
+		for _, w := range warnings {
+			log.Printf("[DEBUG] converting v1 config to check its parameters: %s", w)
+		}
+	}
+
+	return checkTriggeredParameterDefaults(config), nil
+}
+
+// validateTemplateConfigVariables checks the variables of a config against
+// the template it references. Templates that do not exist yet, e.g.
+// because they are created in the same apply, are only logged.
+func validateTemplateConfigVariables(client *gateclient.GatewayClient, config map[string]interface{}) error {
+	template, _ := config["template"].(map[string]interface{})
+	reference := stringValue(template["reference"])
+	if !strings.HasPrefix(reference, "spinnaker://") {
+		return nil
+	}
+
+	templateID, tag, err := parsePipelineTemplateURL(reference)
+	if err != nil {
+		return err
+	}
+
+	t := make(map[string]interface{})
+	if err := client.GetPipelineTemplate(templateID, tag, &t); err != nil {
+		if err.Error() == gateclient.ErrCodeNoSuchEntityException {
+			log.Printf("[WARN] template %s does not exist yet, variables are not checked", reference)
+			return nil
+		}
+		return err
+	}
+
+	declared, _ := t["variables"].([]interface{})
+	values, _ := config["variables"].(map[string]interface{})
+	if problems := checkTemplateVariables(declared, values); len(problems) > 0 {
+		return fmt.Errorf("pipeline_config does not match the variables of template %s:\n  - %s",
+			reference, strings.Join(problems, "\n  - "))
+	}
+
+	return nil
+}
+
+// validateTemplateConfigV1Variables checks the variables of a v1 config
+// against the template at its source.
+func validateTemplateConfigV1Variables(client *gateclient.GatewayClient, config map[string]interface{}) error {
+	source := templateConfigV1Source(config)
+	if source == "" {
+		return fmt.Errorf("pipeline_config must set pipeline.template.source for v1 templates")
+	}
+
+	t, err := client.ResolvePipelineTemplateV1(source)
+	if err != nil {
+		if err.Error() == gateclient.ErrCodeNoSuchEntityException {
+			log.Printf("[WARN] template %s does not exist yet, variables are not checked", source)
+			return nil
+		}
+		return err
+	}
+
+	declared, _ := t["variables"].([]interface{})
+	pipeline, _ := config["pipeline"].(map[string]interface{})
+	values, _ := pipeline["variables"].(map[string]interface{})
+	if problems := checkTemplateVariables(convertTemplateVariablesV1(declared), values); len(problems) > 0 {
+		return fmt.Errorf("pipeline_config does not match the variables of template %s:\n  - %s",
+			source, strings.Join(problems, "\n  - "))
+	}
+
+	return nil
 }
 
 func resourcePipelineTemplateConfigCreate(data *schema.ResourceData, meta interface{}) error {
 	clientConfig := meta.(gateConfig)
 	client := clientConfig.client
 
-	pConfig, err := buildConfig(data)
+	pConfig, err := buildConfig(data, client)
 
 	if err != nil {
 		return err
@@ -56,7 +346,7 @@ func resourcePipelineTemplateConfigCreate(data *schema.ResourceData, meta interf
 		return err
 	}
 
-	data.SetId(pConfig.Application + ":" + pConfig.Name)
+	data.SetId(templateConfigID(pConfig.Application, pConfig.Name))
 
 	return resourcePipelineTemplateConfigRead(data, meta)
 }
@@ -67,12 +357,14 @@ func resourcePipelineTemplateConfigRead(data *schema.ResourceData, meta interfac
 
 	id := data.Id()
 
-	parts := strings.Split(id, ":")
-	application := parts[0]
-	name := parts[1]
+	application, name, err := parseTemplateConfigID(id)
+	if err != nil {
+		return err
+	}
 
 	p := PipelineConfig{}
-	if _, err := client.GetPipeline(application, name, &p); err != nil {
+	jsonMap, err := client.GetPipeline(application, name, &p)
+	if err != nil {
 		if err.Error() == gateclient.ErrCodeNoSuchEntityException {
 			data.SetId("")
 			return nil
@@ -80,6 +372,42 @@ func resourcePipelineTemplateConfigRead(data *schema.ResourceData, meta interfac
 		return err
 	}
 
+	// locked is managed through the locked block rather than the config.
+	locked := flattenPipelineLocked(p.Locked)
+	p.Locked = nil
+	deleteKeys(p.Extra, templateConfigManagedKeys)
+
+	// v1 configs are kept as they were written, under config.
+	if p.Config != nil {
+		jsonContent, err := json.Marshal(p.Config)
+		if err != nil {
+			return err
+		}
+
+		raw, err := yaml.JSONToYAML(jsonContent)
+		if err != nil {
+			return err
+		}
+
+		data.Set("template_name", name)
+		data.Set("application", application)
+		data.Set("pipeline_config", string(raw))
+		data.Set("locked", locked)
+		data.SetId(id)
+		return nil
+	}
+
+	if templateConfigStructured(data) {
+		if err := flattenTemplateConfig(data, jsonMap, client); err != nil {
+			return err
+		}
+		data.Set("template_name", name)
+		data.Set("application", application)
+		data.Set("locked", locked)
+		data.SetId(id)
+		return nil
+	}
+
 	jsonContent, err := json.Marshal(p)
 	if err != nil {
 		return err
@@ -93,6 +421,7 @@ func resourcePipelineTemplateConfigRead(data *schema.ResourceData, meta interfac
 	data.Set("template_name", name)
 	data.Set("application", application)
 	data.Set("pipeline_config", string(raw))
+	data.Set("locked", locked)
 
 	data.SetId(id)
 
@@ -103,11 +432,10 @@ func resourcePipelineTemplateConfigUpdate(data *schema.ResourceData, meta interf
 	clientConfig := meta.(gateConfig)
 	client := clientConfig.client
 
-	id := data.Id()
-
-	parts := strings.Split(id, ":")
-	application := parts[0]
-	name := parts[1]
+	application, name, err := parseTemplateConfigID(data.Id())
+	if err != nil {
+		return err
+	}
 
 	p := PipelineConfig{}
 	if _, err := client.GetPipeline(application, name, &p); err != nil {
@@ -118,7 +446,7 @@ func resourcePipelineTemplateConfigUpdate(data *schema.ResourceData, meta interf
 		return err
 	}
 
-	pConfig, err := buildConfig(data)
+	pConfig, err := buildConfig(data, client)
 	if err != nil {
 		return err
 	}
@@ -142,7 +470,7 @@ func resourcePipelineTemplateConfigUpdate(data *schema.ResourceData, meta interf
 	data.Set("application", application)
 	data.Set("pipeline_config", string(raw))
 
-	data.SetId(application + ":" + pConfig.Name)
+	data.SetId(templateConfigID(application, pConfig.Name))
 
 	return resourcePipelineTemplateConfigRead(data, meta)
 }
@@ -151,11 +479,10 @@ func resourcePipelineTemplateConfigDelete(data *schema.ResourceData, meta interf
 	clientConfig := meta.(gateConfig)
 	client := clientConfig.client
 
-	id := data.Id()
-
-	parts := strings.Split(id, ":")
-	application := parts[0]
-	name := data.Get("template_name").(string)
+	application, name, err := parseTemplateConfigID(data.Id())
+	if err != nil {
+		return err
+	}
 
 	if err := client.DeletePipeline(application, name); err != nil {
 		return err
@@ -170,11 +497,10 @@ func resourcePipelineTemplateConfigExists(data *schema.ResourceData, meta interf
 	clientConfig := meta.(gateConfig)
 	client := clientConfig.client
 
-	id := data.Id()
-
-	parts := strings.Split(id, ":")
-	application := parts[0]
-	name := data.Get("template_name").(string)
+	application, name, err := parseTemplateConfigID(data.Id())
+	if err != nil {
+		return false, err
+	}
 
 	p := PipelineConfig{}
 	if _, err := client.GetPipeline(application, name, &p); err != nil {
@@ -191,11 +517,17 @@ func resourcePipelineTemplateConfigExists(data *schema.ResourceData, meta interf
 	return false, nil
 }
 
-func buildConfig(data *schema.ResourceData) (*PipelineConfig, error) {
+func buildConfig(data *schema.ResourceData, client *gateclient.GatewayClient) (*PipelineConfig, error) {
 	config := data.Get("pipeline_config").(string)
 	tName := data.Get("template_name").(string)
 
-	d, err := yaml.YAMLToJSON([]byte(config))
+	var d []byte
+	var err error
+	if templateConfigStructured(data) {
+		d, err = expandStructuredTemplateConfig(data, client)
+	} else {
+		d, err = yaml.YAMLToJSON([]byte(config))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -205,6 +537,10 @@ func buildConfig(data *schema.ResourceData) (*PipelineConfig, error) {
 		return nil, fmt.Errorf("Error decoding json: %s", err.Error())
 	}
 
+	if templateSchema(jsonContent) == templateSchemaV1 {
+		return buildConfigV1(data, jsonContent)
+	}
+
 	_, ok := jsonContent["name"].(string)
 	if !ok {
 		return nil, fmt.Errorf("pipeline name not set in configuration")
@@ -219,10 +555,48 @@ func buildConfig(data *schema.ResourceData) (*PipelineConfig, error) {
 	json.Unmarshal(d, &pConfig)
 	pConfig.Type = "templatedPipeline"
 	pConfig.Name = tName
+	pConfig.Locked = expandPipelineLocked(data)
 
 	return &pConfig, err
 }
 
+// buildConfigV1 wraps a v1 config in the templated pipeline that carries
+// it.
+func buildConfigV1(data *schema.ResourceData, config map[string]interface{}) (*PipelineConfig, error) {
+	if _, ok := config["pipeline"].(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("pipeline not set in v1 pipeline configuration")
+	}
+	if templateConfigV1Source(config) == "" {
+		return nil, fmt.Errorf("pipeline.template.source not set in v1 pipeline configuration")
+	}
+
+	return &PipelineConfig{
+		Type:        "templatedPipeline",
+		Name:        data.Get("template_name").(string),
+		Application: data.Get("application").(string),
+		Config:      config,
+		Locked:      expandPipelineLocked(data),
+	}, nil
+}
+
+// expandStructuredTemplateConfig encodes the config built from the
+// structured attributes, with the upstream pipelines of pipeline triggers
+// resolved, so it goes through the same path as pipeline_config.
+func expandStructuredTemplateConfig(data *schema.ResourceData, client *gateclient.GatewayClient) ([]byte, error) {
+	config, err := expandTemplateConfig(data, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if pipelineTriggersManaged(data) {
+		if config["triggers"], err = expandPipelineTriggers(data, client); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(config)
+}
+
 func suppressEquivalentPipelineConfigDiffs(k, old, new string, d *schema.ResourceData) bool {
 	equivalent, err := areRoughlyEqualJSON(old, new)
 	if err != nil {
@@ -233,8 +607,8 @@ func suppressEquivalentPipelineConfigDiffs(k, old, new string, d *schema.Resourc
 }
 
 func areRoughlyEqualJSON(s1 string, s2 string) (bool, error) {
-	var o1 PipelineConfig
-	var o2 PipelineConfig
+	var o1 map[string]interface{}
+	var o2 map[string]interface{}
 
 	var err error
 	log.Printf("[DEBUG] s1: %s", s1)
@@ -247,16 +621,21 @@ func areRoughlyEqualJSON(s1 string, s2 string) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("Error mashalling string 2 :: %s", err.Error())
 	}
+	if o1 == nil {
+		o1 = make(map[string]interface{})
+	}
+	if o2 == nil {
+		o2 = make(map[string]interface{})
+	}
 
-	equal := true
-	if len(o1.ID) > 0 && len(o2.ID) > 0 {
-		equal = o1.ID == o2.ID
+	// The id is only known once the config is saved.
+	if stringValue(o1["id"]) == "" || stringValue(o2["id"]) == "" {
+		delete(o1, "id")
+		delete(o2, "id")
 	}
 
-	equal = equal && o1.Application == o2.Application
-	equal = equal && o1.Description == o2.Description
-	equal = equal && reflect.DeepEqual(o1.Variables, o2.Variables)
-	equal = equal && reflect.DeepEqual(o1.Template, o2.Template)
+	normalizeTemplateConfig(o1)
+	normalizeTemplateConfig(o2)
 
-	return equal, nil
+	return reflect.DeepEqual(o1, o2), nil
 }
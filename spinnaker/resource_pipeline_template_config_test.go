@@ -1,9 +1,11 @@
 package spinnaker
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-	"strings"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -69,12 +71,13 @@ func testAccCheckPipelineTemplateConfigExists(n string) resource.TestCheckFunc {
 			return fmt.Errorf("No Application ID is set")
 		}
 
-		parts := strings.Split(rs.Primary.ID, ":")
-		applicationName := parts[0]
-		templateName := parts[1]
+		applicationName, templateName, err := parseTemplateConfigID(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
 
 		client := testAccProvider.Meta().(gateConfig).client
-		err := resource.Retry(1*time.Minute, func() *resource.RetryError {
+		err = resource.Retry(1*time.Minute, func() *resource.RetryError {
 			_, resp, err := client.ApplicationControllerApi.GetPipelineConfigUsingGET(client.Context,
 				applicationName,
 				templateName)
@@ -133,3 +136,161 @@ resource "spinnaker_pipeline_template_config" "test2" {
 }
 `, templateName, appName)
 }
+
+func TestTemplateConfigID(t *testing.T) {
+	cases := []struct {
+		application, name, id string
+	}{
+		{"docta", "Build and Deploy EKS", "docta/Build and Deploy EKS"},
+		{"docta", "deploy: prod", "docta/deploy: prod"},
+		{"docta", "build/deploy 100%", "docta/build%2Fdeploy 100%25"},
+	}
+
+	for _, c := range cases {
+		id := templateConfigID(c.application, c.name)
+		if id != c.id {
+			t.Errorf("templateConfigID(%q, %q) = %q, want %q", c.application, c.name, id, c.id)
+		}
+
+		application, name, err := parseTemplateConfigID(id)
+		if err != nil {
+			t.Errorf("parseTemplateConfigID(%q): %s", id, err)
+			continue
+		}
+		if application != c.application || name != c.name {
+			t.Errorf("parseTemplateConfigID(%q) = %q, %q", id, application, name)
+		}
+	}
+}
+
+func TestParseTemplateConfigIDInvalid(t *testing.T) {
+	for _, id := range []string{
+		"",
+		"docta",
+		"docta/",
+		"/deploy",
+		"docta/build/deploy",
+		"docta/100%",
+		"doc ta/deploy",
+	} {
+		if _, _, err := parseTemplateConfigID(id); err == nil {
+			t.Errorf("parseTemplateConfigID(%q) should fail", id)
+		}
+	}
+}
+
+func TestResourcePipelineTemplateConfigStateUpgradeV0(t *testing.T) {
+	cases := []struct {
+		name     string
+		rawState map[string]interface{}
+		id       string
+	}{
+		{
+			name: "from attributes",
+			rawState: map[string]interface{}{
+				"id":            "docta:deploy: prod",
+				"application":   "docta",
+				"template_name": "deploy: prod",
+			},
+			id: "docta/deploy: prod",
+		},
+		{
+			name:     "from id",
+			rawState: map[string]interface{}{"id": "docta:build/deploy"},
+			id:       "docta/build%2Fdeploy",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			state, err := resourcePipelineTemplateConfigStateUpgradeV0(context.Background(), c.rawState, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state["id"] != c.id {
+				t.Errorf("id = %q, want %q", state["id"], c.id)
+			}
+		})
+	}
+
+	if _, err := resourcePipelineTemplateConfigStateUpgradeV0(context.Background(),
+		map[string]interface{}{"id": "docta"}, nil); err == nil {
+		t.Error("upgrading a malformed ID should fail")
+	}
+}
+
+func TestResourcePipelineTemplateConfigV0Schema(t *testing.T) {
+	attributes := resourcePipelineTemplateConfigV0().CoreConfigSchema().Attributes
+
+	var names []string
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	expected := []string{"application", "id", "pipeline_config", "template_name"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected the v0 schema to have %v, got %v", expected, names)
+	}
+}
+
+func TestResourcePipelineTemplateConfigImport(t *testing.T) {
+	cases := []struct {
+		importID, application, name, id string
+	}{
+		{"docta/Build and Deploy EKS", "docta", "Build and Deploy EKS", "docta/Build and Deploy EKS"},
+		{"docta:Build and Deploy EKS", "docta", "Build and Deploy EKS", "docta/Build and Deploy EKS"},
+		{"docta:deploy: prod", "docta", "deploy: prod", "docta/deploy: prod"},
+	}
+
+	for _, c := range cases {
+		data := resourcePipelineTemplateConfig().TestResourceData()
+		data.SetId(c.importID)
+
+		imported, err := resourcePipelineTemplateConfigImport(context.Background(), data, gateConfig{})
+		if err != nil {
+			t.Errorf("import %q: %s", c.importID, err)
+			continue
+		}
+		d := imported[0]
+		if d.Id() != c.id || d.Get("application") != c.application || d.Get("template_name") != c.name {
+			t.Errorf("import %q = %q (%v, %v)", c.importID, d.Id(), d.Get("application"), d.Get("template_name"))
+		}
+	}
+
+	for _, importID := range []string{"docta", ":deploy", "docta/a/b"} {
+		data := resourcePipelineTemplateConfig().TestResourceData()
+		data.SetId(importID)
+		if _, err := resourcePipelineTemplateConfigImport(context.Background(), data, gateConfig{}); err == nil {
+			t.Errorf("import %q should fail", importID)
+		}
+	}
+}
+
+func TestValidateTemplateConfigParameterDefaults(t *testing.T) {
+	config := `
+schema: "1"
+pipeline:
+  application: app
+  name: Deploy
+  template:
+    source: spinnaker://deploy-template
+configuration:
+  parameters:
+  - name: version
+    required: true
+  triggers:
+  - type: cron
+    enabled: true
+    cronExpression: 0 0 12 * * ?
+`
+
+	warnings, errs := validateTemplateConfigParameterDefaults(config, "pipeline_config")
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+	expected := `required parameter "version" has no default but the pipeline has automated triggers`
+	if len(warnings) != 1 || warnings[0] != expected {
+		t.Fatalf("expected warning %q, got %q", expected, warnings)
+	}
+}
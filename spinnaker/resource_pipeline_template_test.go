@@ -3,6 +3,7 @@ package spinnaker
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -64,7 +65,7 @@ func testAccCheckPipelineTemplateExists(n string) resource.TestCheckFunc {
 		}
 		client := testAccProvider.Meta().(gateConfig).client
 		err := resource.Retry(1*time.Minute, func() *resource.RetryError {
-			_, resp, err := client.PipelineTemplatesControllerApi.GetUsingGET(client.Context, rs.Primary.ID)
+			_, resp, err := client.V2PipelineTemplatesControllerApi.GetUsingGET2(client.Context, rs.Primary.ID, nil)
 
 			if resp != nil {
 				if resp != nil && resp.StatusCode == http.StatusNotFound {
@@ -115,3 +116,38 @@ resource "spinnaker_pipeline_template" "test1" {
 }
 `, rName)
 }
+
+func TestPipelineTemplateDependentNames(t *testing.T) {
+	dependents := []map[string]interface{}{
+		{"application": "web", "name": "Deploy Prod"},
+		{"application": "api", "name": "Deploy"},
+	}
+
+	names := pipelineTemplateDependentNames(dependents)
+	expected := "api/Deploy, web/Deploy Prod"
+	if got := strings.Join(names, ", "); got != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestAreEqualJSONIgnoresTemplateID(t *testing.T) {
+	equal, err := areEqualJSON("id: old-name\nschema: v2\n", `{"id": "new-name", "schema": "v2"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal {
+		t.Fatal("expected templates differing only in id to be equal")
+	}
+}
+
+func TestCheckPipelineTemplateID(t *testing.T) {
+	if err := checkPipelineTemplateID(map[string]interface{}{"schema": "v2"}, "deploy"); err != nil {
+		t.Fatalf("expected a template without id to pass, got %s", err)
+	}
+	if err := checkPipelineTemplateID(map[string]interface{}{"id": "deploy"}, "deploy"); err != nil {
+		t.Fatalf("expected a matching id to pass, got %s", err)
+	}
+	if err := checkPipelineTemplateID(map[string]interface{}{"id": "deploy-old"}, "deploy"); err == nil {
+		t.Fatal("expected a mismatched id to fail")
+	}
+}
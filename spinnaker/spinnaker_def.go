@@ -26,9 +26,15 @@ type PipelineConfig struct {
 	Name        string                   `json:"name"`
 	Application string                   `json:"application"`
 	Description string                   `json:"description,omitempty"`
-	Parameters  []map[string]interface{} `json:"parameterConfig,omitempty"`
+	Parameters  []map[string]interface{} `json:"parameterConfig,omitempty" mapstructure:"parameterConfig"`
 	Variables   map[string]interface{}   `json:"variables,omitempty"`
 	Template    map[string]interface{}   `json:"template,omitempty"`
+	Locked      map[string]interface{}   `json:"locked,omitempty"`
+	Config      map[string]interface{}   `json:"config,omitempty"`
+
+	// Extra holds every other field of the config, so fields the provider
+	// does not model are still saved and compared.
+	Extra map[string]interface{} `json:"-" mapstructure:",remain"`
 }
 
 type templateRead struct {
@@ -0,0 +1,267 @@
+package spinnaker
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Managed Pipeline Templates v1 declare schema "1". v1 templates are
+// referenced by a source, which is either a spinnaker://<template id>
+// reference or a URL the template is hosted at, and v1 templated pipelines
+// keep their config under a "config" key.
+
+const (
+	templateSchemaV1 = "1"
+	templateSchemaV2 = "v2"
+)
+
+// templateSchema returns the schema a template or template config
+// declares, with the YAML number 1 read as "1".
+func templateSchema(doc map[string]interface{}) string {
+	return stringValue(doc["schema"])
+}
+
+// templateConfigV1Source returns the template source of a v1 config.
+func templateConfigV1Source(config map[string]interface{}) string {
+	pipeline, _ := config["pipeline"].(map[string]interface{})
+	template, _ := pipeline["template"].(map[string]interface{})
+	return stringValue(template["source"])
+}
+
+// convertTemplateVariablesV1 converts the variables a v1 template declares
+// to the v2 form. Nullable variables without a default get a null default,
+// since v2 has no other way of making a variable optional.
+func convertTemplateVariablesV1(variables []interface{}) []interface{} {
+	converted := make([]interface{}, 0, len(variables))
+	for _, v := range variables {
+		variable, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		c := map[string]interface{}{
+			"name": variable["name"],
+			"type": variable["type"],
+		}
+		if c["type"] == nil {
+			c["type"] = "string"
+		}
+		if description, ok := variable["description"]; ok {
+			c["description"] = description
+		}
+		if defaultValue, ok := variable["defaultValue"]; ok {
+			c["defaultValue"] = defaultValue
+		} else if nullable, _ := variable["nullable"].(bool); nullable {
+			c["defaultValue"] = nil
+		}
+		converted = append(converted, c)
+	}
+	return converted
+}
+
+// convertConfigurationV1 moves the settings of a v1 "configuration" block
+// to where v2 keeps them.
+func convertConfigurationV1(configuration map[string]interface{}, dest map[string]interface{}) {
+	for from, to := range map[string]string{
+		"triggers":          "triggers",
+		"parameters":        "parameterConfig",
+		"notifications":     "notifications",
+		"expectedArtifacts": "expectedArtifacts",
+	} {
+		if items, _ := configuration[from].([]interface{}); len(items) > 0 {
+			dest[to] = items
+		}
+	}
+
+	concurrent, _ := configuration["concurrentExecutions"].(map[string]interface{})
+	if parallel, ok := concurrent["parallel"].(bool); ok {
+		dest["limitConcurrent"] = !parallel
+	}
+	if blocking, ok := concurrent["blocking"].(bool); ok {
+		dest["keepWaitingPipelines"] = blocking
+	}
+}
+
+// convertTemplateConfigV1 converts a v1 templated pipeline config to the v2
+// form, along with warnings for anything that could not be carried over.
+func convertTemplateConfigV1(config map[string]interface{}) (map[string]interface{}, []string) {
+	var warnings []string
+
+	pipeline, _ := config["pipeline"].(map[string]interface{})
+	converted := map[string]interface{}{
+		"schema":      templateSchemaV2,
+		"application": pipeline["application"],
+		"name":        pipeline["name"],
+	}
+
+	source := templateConfigV1Source(config)
+	converted["template"] = pipelineTemplateReference(source)
+	if !pipelineTemplateURLPattern.MatchString(source) {
+		warnings = append(warnings, fmt.Sprintf("template source %q is not a spinnaker://<template id> reference; "+
+			"v2 templates must be saved in Spinnaker, so save the converted template and reference it instead", source))
+	}
+
+	if variables, _ := pipeline["variables"].(map[string]interface{}); len(variables) > 0 {
+		converted["variables"] = variables
+	}
+
+	configuration, _ := config["configuration"].(map[string]interface{})
+	if inherit, _ := configuration["inherit"].([]interface{}); len(inherit) > 0 {
+		converted["inherit"] = inherit
+	}
+	convertConfigurationV1(configuration, converted)
+
+	if stages, _ := config["stages"].([]interface{}); len(stages) > 0 {
+		convertedStages := make([]interface{}, 0, len(stages))
+		for _, s := range stages {
+			stage, _ := s.(map[string]interface{})
+			convertedStages = append(convertedStages, convertTemplateStageV1(stage, &warnings))
+		}
+		converted["stages"] = convertedStages
+	}
+
+	for _, key := range []string{"modules", "partials"} {
+		if v, ok := config[key]; ok && v != nil {
+			warnings = append(warnings, fmt.Sprintf("%s are not supported by v2 templates and were dropped", key))
+		}
+	}
+
+	return converted, warnings
+}
+
+// convertPipelineTemplateV1 converts a v1 pipeline template to the v2 form,
+// along with warnings for anything that could not be carried over. Jinja
+// references to template variables become SpEL templateVariables
+// references; other Jinja expressions are reported.
+func convertPipelineTemplateV1(template map[string]interface{}) (map[string]interface{}, []string) {
+	var warnings []string
+
+	converted := map[string]interface{}{
+		"schema": templateSchemaV2,
+		"id":     template["id"],
+	}
+	for _, key := range []string{"metadata", "protect"} {
+		if v, ok := template[key]; ok {
+			converted[key] = v
+		}
+	}
+
+	variables, _ := template["variables"].([]interface{})
+	declared := make(map[string]bool, len(variables))
+	for _, v := range variables {
+		variable, _ := v.(map[string]interface{})
+		name := stringValue(variable["name"])
+		declared[name] = true
+		for _, key := range []string{"merge", "remove"} {
+			if set, _ := variable[key].(bool); set {
+				warnings = append(warnings, fmt.Sprintf("variable %q: %s is not supported by v2 templates and was dropped", name, key))
+			}
+		}
+	}
+	converted["variables"] = convertTemplateVariablesV1(variables)
+
+	pipeline := map[string]interface{}{
+		"stages": []interface{}{},
+	}
+	configuration, _ := template["configuration"].(map[string]interface{})
+	convertConfigurationV1(configuration, pipeline)
+
+	stages, _ := template["stages"].([]interface{})
+	for _, s := range stages {
+		stage, _ := s.(map[string]interface{})
+		pipeline["stages"] = append(pipeline["stages"].([]interface{}), convertTemplateStageV1(stage, &warnings))
+	}
+	converted["pipeline"] = pipeline
+
+	if source := stringValue(template["source"]); source != "" {
+		warnings = append(warnings, fmt.Sprintf("the template inherits from %s, which v2 templates cannot do; "+
+			"copy the stages of that template into this one", source))
+	}
+	for _, key := range []string{"modules", "partials"} {
+		if v, ok := template[key]; ok && v != nil {
+			warnings = append(warnings, fmt.Sprintf("%s are not supported by v2 templates and were dropped", key))
+		}
+	}
+
+	return convertJinjaExpressions(converted, declared, "", &warnings).(map[string]interface{}), warnings
+}
+
+// convertTemplateStageV1 turns a v1 template stage, whose settings sit
+// under "config", into a v2 pipeline stage.
+func convertTemplateStageV1(stage map[string]interface{}, warnings *[]string) map[string]interface{} {
+	id := stringValue(stage["id"])
+
+	converted := make(map[string]interface{})
+	if config, ok := stage["config"].(map[string]interface{}); ok {
+		for k, v := range config {
+			converted[k] = v
+		}
+	}
+	converted["refId"] = id
+	converted["type"] = stage["type"]
+	if name, ok := stage["name"]; ok {
+		converted["name"] = name
+	} else {
+		converted["name"] = id
+	}
+
+	requisites := make([]interface{}, 0)
+	if dependsOn, ok := stage["dependsOn"].([]interface{}); ok {
+		requisites = dependsOn
+	}
+	converted["requisiteStageRefIds"] = requisites
+
+	for _, key := range []string{"when", "inject", "loop", "partials"} {
+		if v, ok := stage[key]; ok && v != nil {
+			*warnings = append(*warnings, fmt.Sprintf("stage %q: %s is not supported by v2 templates and was dropped", id, key))
+		}
+	}
+
+	return converted
+}
+
+// jinjaVariablePattern matches a Jinja expression that only references a
+// variable, e.g. {{ regions }} or {{ cluster.name }}.
+var jinjaVariablePattern = regexp.MustCompile(`{{\s*([a-zA-Z_][a-zA-Z0-9_]*)((?:\.[a-zA-Z0-9_]+)*)\s*}}`)
+
+// jinjaPattern matches any Jinja expression or statement.
+var jinjaPattern = regexp.MustCompile(`{{.*?}}|{%.*?%}`)
+
+// convertJinjaExpressions rewrites Jinja references to declared variables in
+// every string of value as SpEL templateVariables references, and reports
+// the Jinja left over. path locates value in the template.
+func convertJinjaExpressions(value interface{}, declared map[string]bool, path string, warnings *[]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		converted := jinjaVariablePattern.ReplaceAllStringFunc(v, func(m string) string {
+			parts := jinjaVariablePattern.FindStringSubmatch(m)
+			if !declared[parts[1]] {
+				return m
+			}
+			return "${ templateVariables." + parts[1] + parts[2] + " }"
+		})
+		if jinjaPattern.MatchString(converted) {
+			*warnings = append(*warnings, fmt.Sprintf("%s: Jinja expression %q could not be converted to SpEL",
+				path, jinjaPattern.FindString(converted)))
+		}
+		return converted
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v[k] = convertJinjaExpressions(v[k], declared, strings.TrimPrefix(path+"."+k, "."), warnings)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = convertJinjaExpressions(v[i], declared, fmt.Sprintf("%s.%d", path, i), warnings)
+		}
+		return v
+	}
+	return value
+}
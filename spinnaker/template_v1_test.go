@@ -0,0 +1,174 @@
+package spinnaker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConvertTemplateVariablesV1(t *testing.T) {
+	template := decodeTestPipeline(t, `{
+		"variables": [
+			{"name": "regions", "type": "list", "description": "Regions to deploy to", "example": "[us-east-1]"},
+			{"name": "replicas", "type": "int", "defaultValue": 1},
+			{"name": "owner", "nullable": true},
+			{"name": "canary", "type": "boolean", "nullable": true, "defaultValue": false}
+		]
+	}`)
+
+	expected := decodeTestPipeline(t, `{
+		"variables": [
+			{"name": "regions", "type": "list", "description": "Regions to deploy to"},
+			{"name": "replicas", "type": "int", "defaultValue": 1},
+			{"name": "owner", "type": "string", "defaultValue": null},
+			{"name": "canary", "type": "boolean", "defaultValue": false}
+		]
+	}`)
+
+	converted := convertTemplateVariablesV1(template["variables"].([]interface{}))
+	if !reflect.DeepEqual(converted, expected["variables"]) {
+		t.Fatalf("expected variables %v, got %v", expected["variables"], converted)
+	}
+
+	problems := checkTemplateVariables(converted, map[string]interface{}{"regions": []interface{}{"us-east-1"}})
+	if len(problems) > 0 {
+		t.Fatalf("expected nullable variables to be optional, got %v", problems)
+	}
+}
+
+func TestConvertTemplateConfigV1(t *testing.T) {
+	config := decodeTestPipeline(t, `{
+		"schema": "1",
+		"pipeline": {
+			"application": "app",
+			"name": "Deploy",
+			"template": {"source": "spinnaker://deploy-template"},
+			"variables": {"regions": ["us-east-1"]}
+		},
+		"configuration": {
+			"inherit": ["triggers"],
+			"concurrentExecutions": {"parallel": true, "blocking": false},
+			"parameters": [{"name": "env", "default": "prod"}],
+			"triggers": [{"type": "cron", "cronExpression": "0 0 12 * * ?"}]
+		},
+		"stages": [
+			{"id": "wait", "type": "wait", "config": {"waitTime": 30}, "dependsOn": ["deploy"]},
+			{"id": "notify", "type": "wait", "name": "Notify", "inject": {"after": ["wait"]}}
+		]
+	}`)
+
+	expected := decodeTestPipeline(t, `{
+		"schema": "v2",
+		"application": "app",
+		"name": "Deploy",
+		"template": {
+			"artifactAccount": "front50ArtifactCredentials",
+			"reference": "spinnaker://deploy-template",
+			"type": "front50/pipelineTemplate"
+		},
+		"variables": {"regions": ["us-east-1"]},
+		"inherit": ["triggers"],
+		"limitConcurrent": false,
+		"keepWaitingPipelines": false,
+		"parameterConfig": [{"name": "env", "default": "prod"}],
+		"triggers": [{"type": "cron", "cronExpression": "0 0 12 * * ?"}],
+		"stages": [
+			{"refId": "wait", "name": "wait", "type": "wait", "requisiteStageRefIds": ["deploy"], "waitTime": 30},
+			{"refId": "notify", "name": "Notify", "type": "wait", "requisiteStageRefIds": []}
+		]
+	}`)
+
+	expectedWarnings := []string{
+		`stage "notify": inject is not supported by v2 templates and was dropped`,
+	}
+
+	converted, warnings := convertTemplateConfigV1(config)
+	if !reflect.DeepEqual(converted, expected) {
+		t.Fatalf("expected config %v, got %v", expected, converted)
+	}
+	if strings.Join(warnings, "\n") != strings.Join(expectedWarnings, "\n") {
+		t.Fatalf("expected warnings:\n%s\ngot:\n%s", strings.Join(expectedWarnings, "\n"), strings.Join(warnings, "\n"))
+	}
+}
+
+func TestConvertTemplateConfigV1HostedTemplate(t *testing.T) {
+	config := decodeTestPipeline(t, `{
+		"schema": "1",
+		"pipeline": {
+			"application": "app",
+			"name": "Deploy",
+			"template": {"source": "https://templates.example.com/deploy.yml"}
+		},
+		"modules": [{"id": "notify"}]
+	}`)
+
+	_, warnings := convertTemplateConfigV1(config)
+	if len(warnings) != 2 ||
+		!strings.Contains(warnings[0], "https://templates.example.com/deploy.yml") ||
+		!strings.Contains(warnings[1], "modules") {
+		t.Fatalf("expected warnings for the hosted template and modules, got %v", warnings)
+	}
+}
+
+func TestConvertPipelineTemplateV1(t *testing.T) {
+	template := decodeTestPipeline(t, `{
+		"schema": "1",
+		"id": "deploy-template",
+		"metadata": {"name": "Deploy", "owner": "team@example.com"},
+		"variables": [
+			{"name": "regions", "type": "list"},
+			{"name": "cluster", "type": "object", "merge": true}
+		],
+		"configuration": {
+			"concurrentExecutions": {"parallel": false, "blocking": true}
+		},
+		"stages": [
+			{
+				"id": "deploy",
+				"type": "deploy",
+				"config": {"regions": "{{ regions }}", "stack": "{{ cluster.stack }}", "detail": "{{ detail | default('x') }}"}
+			},
+			{"id": "wait", "type": "wait", "name": "Wait", "dependsOn": ["deploy"], "when": ["{{ regions }}"], "config": {"waitTime": 30}}
+		]
+	}`)
+
+	expected := decodeTestPipeline(t, `{
+		"schema": "v2",
+		"id": "deploy-template",
+		"metadata": {"name": "Deploy", "owner": "team@example.com"},
+		"variables": [
+			{"name": "regions", "type": "list"},
+			{"name": "cluster", "type": "object"}
+		],
+		"pipeline": {
+			"limitConcurrent": true,
+			"keepWaitingPipelines": true,
+			"stages": [
+				{
+					"refId": "deploy",
+					"name": "deploy",
+					"type": "deploy",
+					"requisiteStageRefIds": [],
+					"regions": "${ templateVariables.regions }",
+					"stack": "${ templateVariables.cluster.stack }",
+					"detail": "{{ detail | default('x') }}"
+				},
+				{"refId": "wait", "name": "Wait", "type": "wait", "requisiteStageRefIds": ["deploy"], "waitTime": 30}
+			]
+		}
+	}`)
+
+	expectedWarnings := []string{
+		`variable "cluster": merge is not supported by v2 templates and was dropped`,
+		`stage "wait": when is not supported by v2 templates and was dropped`,
+		`pipeline.stages.0.detail: Jinja expression "{{ detail | default('x') }}" could not be converted to SpEL`,
+	}
+
+	converted, warnings := convertPipelineTemplateV1(template)
+	if !reflect.DeepEqual(converted, expected) {
+		t.Fatalf("expected template %v, got %v", expected, converted)
+	}
+	if strings.Join(warnings, "\n") != strings.Join(expectedWarnings, "\n") {
+		t.Fatalf("expected warnings:\n%s\ngot:\n%s", strings.Join(expectedWarnings, "\n"), strings.Join(warnings, "\n"))
+	}
+}
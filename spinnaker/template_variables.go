@@ -0,0 +1,119 @@
+package spinnaker
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// parsePipelineTemplateURL splits a spinnaker://<id>[:<tag>] reference into
+// the template ID and tag.
+func parsePipelineTemplateURL(reference string) (id, tag string, err error) {
+	if !pipelineTemplateURLPattern.MatchString(reference) {
+		return "", "", fmt.Errorf("template reference %q is not a spinnaker://<template id>[:<tag>] URL", reference)
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(reference, "spinnaker://"), ":", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1], nil
+	}
+	return parts[0], "", nil
+}
+
+// checkTemplateVariables checks the variables of a template config against
+// those the template declares. It reports required variables that are
+// missing, variables the template does not declare, and values that do not
+// match the declared type. Values containing a ${...} expression are only
+// resolved at runtime, so their type is not checked.
+func checkTemplateVariables(declared []interface{}, values map[string]interface{}) []string {
+	var problems []string
+
+	known := make(map[string]bool, len(declared))
+	for _, d := range declared {
+		variable, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := stringValue(variable["name"])
+		known[name] = true
+
+		value, set := values[name]
+		if !set {
+			if _, hasDefault := variable["defaultValue"]; !hasDefault {
+				problems = append(problems, fmt.Sprintf("variable %q is required by the template but not set", name))
+			}
+			continue
+		}
+
+		if s, ok := value.(string); ok && strings.Contains(s, "${") {
+			continue
+		}
+
+		variableType := stringValue(variable["type"])
+		if !templateVariableHasType(value, variableType) {
+			problems = append(problems, fmt.Sprintf("variable %q must be of type %s, got %s",
+				name, variableType, templateValueType(value)))
+		}
+	}
+
+	var unknown []string
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, fmt.Sprintf("variable %q is not declared by the template", name))
+	}
+
+	return problems
+}
+
+// templateVariableHasType reports whether a decoded value fits a template
+// variable type. Types we do not know are not checked.
+func templateVariableHasType(value interface{}, variableType string) bool {
+	switch variableType {
+	case "int":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "float":
+		_, ok := value.(float64)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "list":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+func templateValueType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case float64:
+		if v == math.Trunc(v) {
+			return "int"
+		}
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
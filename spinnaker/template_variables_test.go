@@ -0,0 +1,89 @@
+package spinnaker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckTemplateVariables(t *testing.T) {
+	template := decodeTestPipeline(t, `{
+		"variables": [
+			{"name": "regions", "type": "list"},
+			{"name": "replicas", "type": "int", "defaultValue": 1},
+			{"name": "canary", "type": "boolean", "defaultValue": false},
+			{"name": "owner", "type": "string"},
+			{"name": "settings", "type": "object", "defaultValue": {}}
+		]
+	}`)
+	config := decodeTestPipeline(t, `{
+		"variables": {
+			"replicas": 1.5,
+			"canary": "yes",
+			"settings": {"timeout": 30},
+			"region": "us-east-1"
+		}
+	}`)
+
+	expected := []string{
+		`variable "regions" is required by the template but not set`,
+		`variable "replicas" must be of type int, got float`,
+		`variable "canary" must be of type boolean, got string`,
+		`variable "owner" is required by the template but not set`,
+		`variable "region" is not declared by the template`,
+	}
+
+	problems := checkTemplateVariables(template["variables"].([]interface{}), config["variables"].(map[string]interface{}))
+	if strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected problems:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(problems, "\n"))
+	}
+}
+
+func TestParsePipelineTemplateURL(t *testing.T) {
+	cases := []struct {
+		reference, id, tag string
+		valid              bool
+	}{
+		{"spinnaker://deploy", "deploy", "", true},
+		{"spinnaker://deploy:v2.1", "deploy", "v2.1", true},
+		{"https://example.com/template.yml", "", "", false},
+		{"spinnaker://", "", "", false},
+	}
+
+	for _, c := range cases {
+		id, tag, err := parsePipelineTemplateURL(c.reference)
+		if (err == nil) != c.valid {
+			t.Fatalf("%s: expected valid=%v, got error %v", c.reference, c.valid, err)
+		}
+		if id != c.id || tag != c.tag {
+			t.Fatalf("%s: expected %q/%q, got %q/%q", c.reference, c.id, c.tag, id, tag)
+		}
+	}
+}
+
+func TestCheckTemplateVariablesExpressions(t *testing.T) {
+	template := decodeTestPipeline(t, `{
+		"variables": [
+			{"name": "replicas", "type": "int"},
+			{"name": "regions", "type": "list"},
+			{"name": "settings", "type": "object"},
+			{"name": "canary", "type": "boolean"}
+		]
+	}`)
+	config := decodeTestPipeline(t, `{
+		"variables": {
+			"replicas": "${trigger.parameters.count}",
+			"regions": "${ #readJson(parameters.regions) }",
+			"settings": "${trigger.payload.settings}",
+			"canary": "yes"
+		}
+	}`)
+
+	expected := []string{
+		`variable "canary" must be of type boolean, got string`,
+	}
+
+	problems := checkTemplateVariables(template["variables"].([]interface{}), config["variables"].(map[string]interface{}))
+	if strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected problems:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(problems, "\n"))
+	}
+}
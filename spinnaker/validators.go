@@ -3,6 +3,8 @@ package spinnaker
 import (
 	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
 )
 
 func validateApplicationName(v interface{}, k string) (ws []string, errors []error) {
@@ -20,3 +22,132 @@ func validateTemplateName(v interface{}, k string) (ws []string, errors []error)
 	}
 	return
 }
+
+func validateTemplateTag(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if !regexp.MustCompile(`^[a-zA-Z0-9._-]+$`).MatchString(value) {
+		errors = append(errors, fmt.Errorf("Only alphanumeric characters, '.', '_' or '-' allowed in %q", k))
+	}
+	return
+}
+
+type quartzField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var quartzDayOfMonthSpecial = regexp.MustCompile(`^(L(-\d{1,2})?|LW|\d{1,2}W)$`)
+
+var quartzFields = []quartzField{
+	{name: "seconds", min: 0, max: 59},
+	{name: "minutes", min: 0, max: 59},
+	{name: "hours", min: 0, max: 23},
+	{name: "day-of-month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{name: "day-of-week", min: 1, max: 7, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+	{name: "year", min: 1970, max: 2099},
+}
+
+// validateQuartzCronExpression checks a cron trigger expression. Spinnaker
+// uses Quartz cron syntax, which has a leading seconds field, an optional
+// trailing year and requires '?' in either day-of-month or day-of-week.
+func validateQuartzCronExpression(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	fields := strings.Fields(value)
+	if len(fields) != 6 && len(fields) != 7 {
+		errors = append(errors, fmt.Errorf("%q must have 6 or 7 fields (seconds minutes hours day-of-month month day-of-week [year]), got %q", k, value))
+		return
+	}
+
+	for i, f := range fields {
+		if err := validateQuartzField(quartzFields[i], i, f); err != nil {
+			errors = append(errors, fmt.Errorf("%q has an invalid %s field %q: %s", k, quartzFields[i].name, f, err))
+		}
+	}
+
+	if (fields[3] == "?") == (fields[5] == "?") {
+		errors = append(errors, fmt.Errorf("%q must use '?' in exactly one of day-of-month and day-of-week, got %q", k, value))
+	}
+
+	return
+}
+
+func validateQuartzField(field quartzField, index int, value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item == "" {
+			return fmt.Errorf("empty list item")
+		}
+
+		switch {
+		case item == "*":
+			continue
+		case item == "?":
+			if index != 3 && index != 5 {
+				return fmt.Errorf("'?' is only allowed in day-of-month and day-of-week")
+			}
+			if value != "?" {
+				return fmt.Errorf("'?' cannot be combined with other values")
+			}
+			continue
+		case index == 3 && quartzDayOfMonthSpecial.MatchString(item):
+			if n := strings.TrimRight(strings.TrimPrefix(item, "L-"), "LW"); n != "" {
+				if _, err := quartzValue(field, n); err != nil {
+					return err
+				}
+			}
+			continue
+		case index == 5 && item == "L":
+			continue
+		case index == 5 && strings.HasSuffix(item, "L"):
+			if _, err := quartzValue(field, strings.TrimSuffix(item, "L")); err != nil {
+				return err
+			}
+			continue
+		case index == 5 && strings.Contains(item, "#"):
+			parts := strings.SplitN(item, "#", 2)
+			if _, err := quartzValue(field, parts[0]); err != nil {
+				return err
+			}
+			if n, err := strconv.Atoi(parts[1]); err != nil || n < 1 || n > 5 {
+				return fmt.Errorf("'#' must be followed by a number from 1 to 5")
+			}
+			continue
+		}
+
+		base := item
+		if i := strings.Index(item, "/"); i >= 0 {
+			base = item[:i]
+			if step, err := strconv.Atoi(item[i+1:]); err != nil || step < 1 {
+				return fmt.Errorf("increment %q must be a positive number", item[i+1:])
+			}
+			if base == "*" {
+				continue
+			}
+		}
+
+		// Quartz allows ranges that wrap around, e.g. FRI-MON, so only
+		// the bounds themselves are checked.
+		for _, bound := range strings.SplitN(base, "-", 2) {
+			if _, err := quartzValue(field, bound); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func quartzValue(field quartzField, value string) (int, error) {
+	for i, name := range field.names {
+		if strings.EqualFold(value, name) {
+			return field.min + i, nil
+		}
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < field.min || n > field.max {
+		return 0, fmt.Errorf("%q is not a value from %d to %d", value, field.min, field.max)
+	}
+	return n, nil
+}
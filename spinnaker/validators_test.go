@@ -30,3 +30,45 @@ func TestValidateApplicationName(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateQuartzCronExpression(t *testing.T) {
+	validExpressions := []string{
+		"0 0 12 * * ?",
+		"0 15 10 ? * MON-FRI",
+		"0 0/5 14,18 * * ?",
+		"0 15 10 L * ?",
+		"0 15 10 ? * 6L",
+		"0 15 10 ? * 5L,MON",
+		"0 15 10 ? * 6#3",
+		"0 0 12 1W * ?",
+		"0 0 12 ? JAN-MAR SUN 2030",
+		"*/30 * * ? * *",
+	}
+	for _, v := range validExpressions {
+		_, errors := validateQuartzCronExpression(v, "cron_expression")
+		if len(errors) != 0 {
+			t.Fatalf("%q should be a valid Quartz cron expression: %q", v, errors)
+		}
+	}
+
+	invalidExpressions := []string{
+		"0 12 * * *",
+		"0 0 12 * * *",
+		"0 0 12 ? * ?",
+		"0 60 12 * * ?",
+		"0 0 24 * * ?",
+		"0 0 12 32 * ?",
+		"0 0 12 * FOO ?",
+		"0 0/0 12 * * ?",
+		"0 0 12 ? * 6#6",
+		"0 15 10 ? * 5L,FOO",
+		"0 0 ? * * MON",
+		"",
+	}
+	for _, v := range invalidExpressions {
+		_, errors := validateQuartzCronExpression(v, "cron_expression")
+		if len(errors) == 0 {
+			t.Fatalf("%q should be an invalid Quartz cron expression", v)
+		}
+	}
+}